@@ -0,0 +1,62 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+)
+
+const (
+	// ExitCodeFailed is the exit code used when backups fail a check, as
+	// opposed to the check being unable to run.
+	ExitCodeFailed = 2
+)
+
+type FailedError struct {
+	Problems int
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("backup check failed with %d problems", e.Problems)
+}
+
+func (e *FailedError) ExitCode() int {
+	return ExitCodeFailed
+}
+
+func NewCmdCheck() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "check",
+		Short:         "check backups are fresh, regular and complete",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          check,
+	}
+	flags.AddJobFlag(cmd)
+	return cmd
+}
+
+func check(cmd *cobra.Command, a []string) error {
+	ctx, conf, err := flags.BuildJobConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	problems, err := janitor.Check(ctx, conf)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "ok: backups passed all checks")
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	return &FailedError{Problems: len(problems)}
+}
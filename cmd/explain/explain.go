@@ -0,0 +1,71 @@
+package explain
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+)
+
+func NewCmdExplain() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "explain <key>",
+		Short:         "explain why the janitor would keep or prune a backup",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          explain,
+	}
+	flags.AddJobFlag(cmd)
+	return cmd
+}
+
+func explain(cmd *cobra.Command, a []string) error {
+	key := a[0]
+	ctx, conf, err := flags.BuildJobConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, planErr := janitor.Plan(ctx, conf)
+	if result == nil {
+		return planErr
+	}
+
+	w := cmd.OutOrStdout()
+	for _, b := range result.Backups {
+		if b.Key == key {
+			fmt.Fprintln(w, result.Verdicts[b].Reason)
+			return planErr
+		}
+	}
+
+	verdict := "kept"
+	if keyInList(key, result.Prune) {
+		verdict = "pruned"
+	}
+	for _, obj := range result.Unparsable {
+		if obj.Key == key {
+			fmt.Fprintf(w, "%s: unrecognised object policy, unparsable backup (%v)\n", verdict, obj.Err)
+			return planErr
+		}
+	}
+	for _, obj := range result.NonBackup {
+		if obj.Key == key {
+			fmt.Fprintf(w, "%s: unrecognised object policy, object is not a backup\n", verdict)
+			return planErr
+		}
+	}
+	return fmt.Errorf("no object found for key: %s", key)
+}
+
+func keyInList(key string, list []string) bool {
+	for _, i := range list {
+		if key == i {
+			return true
+		}
+	}
+	return false
+}
@@ -1,18 +1,105 @@
 package flags
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/tlmiller/gitlab-janitor/pkg/config"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
 )
 
 const (
 	Config = "config"
 	Debug  = "debug"
 	DryRun = "dry-run"
+	Job    = "job"
+	Output = "output"
 )
 
+// runID ties together every line a command logs, including those logged
+// before its config is read.
+var runID = janitor.NewRunID()
+
+// StartRun adds the run ID to every line logged by the default logger, set
+// up before any command runs.
+func StartRun() {
+	logging.SetDefault(logging.Default().With(logging.FieldRunID, runID))
+}
+
 func BindFlags(c *cobra.Command, v *viper.Viper) {
 	v.BindPFlag(config.KeyDryRun, c.Flags().Lookup(DryRun))
 }
+
+// BuildConfig builds the config and sets up logging from it, returning a
+// context for a single run. Every line logged carries the run ID, including
+// those logged while reading the config. Debug logging is enabled before the
+// config is read when asked for with the debug flag.
+func BuildConfig(c *cobra.Command) (context.Context, *config.Config, error) {
+	StartRun()
+	debug, err := c.Flags().GetBool(Debug)
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing flag debug: %v", err)
+	}
+	if debug {
+		logger := logging.New(c.ErrOrStderr(), logging.LevelDebug, logging.FormatText)
+		logging.SetDefault(logger.With(logging.FieldRunID, runID))
+	}
+
+	builder := config.NewBuilder()
+	BindFlags(c, builder.Viper)
+	confFile, err := c.Flags().GetString(Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing flag config: %v", err)
+	}
+
+	var (
+		conf *config.Config
+	)
+	if confFile != "" {
+		conf, err = builder.BuildWithConfFile(confFile)
+	} else {
+		conf, err = builder.Build()
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("building config: %v", err)
+	}
+
+	if debug {
+		conf.Log.Level = logging.LevelDebug.String()
+	}
+	logger, err := config.ToLogger(conf.Log, c.ErrOrStderr())
+	if err != nil {
+		return nil, nil, fmt.Errorf("building logger: %v", err)
+	}
+	logging.SetDefault(logger.With(logging.FieldRunID, runID))
+	return janitor.WithRunID(context.Background(), runID), conf, nil
+}
+
+// AddJobFlag adds the flag selecting a single job from the config.
+func AddJobFlag(c *cobra.Command) {
+	c.Flags().StringP(Job, "j", "", "name of the job to use from the config")
+}
+
+// BuildJobConfig builds the config for the job selected with the job flag.
+// The flag may be left out when the config does not define multiple jobs.
+func BuildJobConfig(c *cobra.Command) (context.Context, *config.Config, error) {
+	job, err := c.Flags().GetString(Job)
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing flag job: %v", err)
+	}
+
+	ctx, conf, err := BuildConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf, err = config.ToJobConfig(conf, job)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, conf, nil
+}
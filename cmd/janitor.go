@@ -3,17 +3,35 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/tlmiller/gitlab-janitor/cmd/check"
+	"github.com/tlmiller/gitlab-janitor/cmd/explain"
 	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/cmd/plan"
+	"github.com/tlmiller/gitlab-janitor/cmd/restore"
 	"github.com/tlmiller/gitlab-janitor/cmd/run"
+	"github.com/tlmiller/gitlab-janitor/cmd/schema"
+	"github.com/tlmiller/gitlab-janitor/cmd/serve"
+	"github.com/tlmiller/gitlab-janitor/cmd/validate"
 )
 
 func New() (command *cobra.Command) {
 	cmd := &cobra.Command{
 		Use:   "janitor",
 		Short: "gitlab backup janitor",
+		PersistentPreRun: func(*cobra.Command, []string) {
+			flags.StartRun()
+		},
 	}
 	cmd.PersistentFlags().StringP(flags.Config, "c", "", "configuration file")
+	cmd.PersistentFlags().Bool(flags.Debug, false, "log debug messages, including why each object was kept or skipped")
 	cmd.PersistentFlags().Bool(flags.DryRun, false, "dry run mode, no data is deleted")
+	cmd.AddCommand(check.NewCmdCheck())
+	cmd.AddCommand(explain.NewCmdExplain())
+	cmd.AddCommand(plan.NewCmdPlan())
+	cmd.AddCommand(restore.NewCmdRestore())
 	cmd.AddCommand(run.NewCmdRun())
+	cmd.AddCommand(schema.NewCmdSchema())
+	cmd.AddCommand(serve.NewCmdServe())
+	cmd.AddCommand(validate.NewCmdValidate())
 	return cmd
 }
@@ -0,0 +1,186 @@
+package plan
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+)
+
+const (
+	OutputCSV   = "csv"
+	OutputJSON  = "json"
+	OutputTable = "table"
+
+	VerdictKeep  = "keep"
+	VerdictPrune = "prune"
+)
+
+type Entry struct {
+	Key     string    `json:"key"`
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+	Edition string    `json:"edition"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	MD5     string    `json:"md5"`
+	Verdict string    `json:"verdict"`
+}
+
+func NewCmdPlan() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "plan",
+		Short:         "print which backups the janitor would keep and prune",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          plan,
+	}
+	flags.AddJobFlag(cmd)
+	cmd.Flags().StringP(flags.Output, "o", OutputTable, "output format, one of table, json or csv")
+	return cmd
+}
+
+func plan(cmd *cobra.Command, a []string) error {
+	output, err := cmd.Flags().GetString(flags.Output)
+	if err != nil {
+		return fmt.Errorf("missing flag output: %v", err)
+	}
+	if err := checkOutput(output); err != nil {
+		return err
+	}
+
+	ctx, conf, err := flags.BuildJobConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, planErr := janitor.Plan(ctx, conf)
+	if result == nil {
+		return planErr
+	}
+
+	if err := write(cmd.OutOrStdout(), output, Entries(result)); err != nil {
+		return err
+	}
+	return planErr
+}
+
+// checkOutput fails on an unknown output format before anything is listed.
+func checkOutput(output string) error {
+	switch output {
+	case OutputCSV, OutputJSON, OutputTable:
+		return nil
+	}
+	return fmt.Errorf("unknown output format: %s", output)
+}
+
+func write(w io.Writer, output string, entries []Entry) error {
+	switch output {
+	case OutputCSV:
+		return writeCSV(w, entries)
+	case OutputJSON:
+		return writeJSON(w, entries)
+	case OutputTable:
+		return writeTable(w, entries)
+	}
+	return checkOutput(output)
+}
+
+// Entries lists every backup in the result followed by any other objects
+// that are to be pruned by an unrecognised object policy.
+func Entries(result *backup.PruneResult) []Entry {
+	entries := []Entry{}
+	backups := map[string]bool{}
+	for _, b := range result.Backups {
+		backups[b.Key] = true
+		entry := Entry{
+			Key:     b.Key,
+			Time:    b.Time,
+			Edition: b.Edition,
+			Size:    b.Size,
+			ModTime: b.ModTime,
+			MD5:     b.MD5Hex(),
+			Verdict: VerdictPrune,
+		}
+		if b.Version != nil {
+			entry.Version = b.Version.Original()
+		}
+		if result.Verdicts[b].Keep {
+			entry.Verdict = VerdictKeep
+		}
+		entries = append(entries, entry)
+	}
+
+	objects := map[string]*backup.Object{}
+	for _, obj := range result.NonBackup {
+		objects[obj.Key] = obj
+	}
+	for _, obj := range result.Unparsable {
+		objects[obj.Key] = &obj.Object
+	}
+	for _, key := range result.Prune {
+		obj, exists := objects[key]
+		if backups[key] || !exists {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:     obj.Key,
+			Time:    obj.ModTime,
+			Size:    obj.Size,
+			ModTime: obj.ModTime,
+			MD5:     hex.EncodeToString(obj.MD5),
+			Verdict: VerdictPrune,
+		})
+	}
+	return entries
+}
+
+func writeCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"key", "time", "version", "edition", "size", "mod_time", "md5", "verdict"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := cw.Write([]string{
+			e.Key,
+			e.Time.Format(time.RFC3339),
+			e.Version,
+			e.Edition,
+			strconv.FormatInt(e.Size, 10),
+			e.ModTime.Format(time.RFC3339),
+			e.MD5,
+			e.Verdict,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeTable(w io.Writer, entries []Entry) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTIME\tVERSION\tEDITION\tSIZE\tVERDICT")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			e.Key, e.Time.Format(time.RFC3339), e.Version, e.Edition, e.Size, e.Verdict)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,197 @@
+package plan
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+func TestEntries(t *testing.T) {
+	parser := backup.WithGitLabKeyParser()
+	kept, err := parser.Parse("1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing backup key: %v", err)
+	}
+	pruned, err := parser.Parse("1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing backup key: %v", err)
+	}
+	pruned.Size = 10
+	pruned.MD5 = []byte{0xab, 0xcd}
+	unversioned, err := parser.Parse("nightly_gitlab_backup.tar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing backup key: %v", err)
+	}
+
+	modTime := time.Unix(1565056820, 0)
+	unparsable := &backup.UnparsableObject{
+		Object: backup.Object{Key: "1565056820_2019_08_06_not!a!version_gitlab_backup.tar", ModTime: modTime, Size: 3},
+		Err:    errors.New("bad version"),
+	}
+	nonBackup := &backup.Object{Key: "notes.txt", MD5: []byte{0x01}, ModTime: modTime, Size: 5}
+	ignored := &backup.Object{Key: "README.md", ModTime: modTime}
+
+	tests := []struct {
+		Result  *backup.PruneResult
+		Entries []Entry
+	}{
+		{
+			Result:  &backup.PruneResult{},
+			Entries: []Entry{},
+		},
+		{
+			Result: &backup.PruneResult{
+				Listing: backup.Listing{
+					Backups: backup.BackupList{kept, pruned, unversioned},
+				},
+				Verdicts: map[*backup.Backup]backup.Verdict{
+					kept: {Keep: true},
+				},
+				Prune: []string{pruned.Key, unversioned.Key},
+			},
+			Entries: []Entry{
+				{Key: kept.Key, Time: kept.Time, Version: "12.0.3", Edition: "ee", Verdict: VerdictKeep},
+				{Key: pruned.Key, Time: pruned.Time, Version: "12.0.3", Edition: "ee", Size: 10, MD5: "abcd", Verdict: VerdictPrune},
+				{Key: unversioned.Key, Verdict: VerdictPrune},
+			},
+		},
+		{
+			Result: &backup.PruneResult{
+				Listing: backup.Listing{
+					Backups:    backup.BackupList{kept},
+					Unparsable: []*backup.UnparsableObject{unparsable},
+					NonBackup:  []*backup.Object{nonBackup, ignored},
+				},
+				Verdicts: map[*backup.Backup]backup.Verdict{
+					kept: {Keep: true},
+				},
+				Prune: []string{nonBackup.Key, unparsable.Key},
+			},
+			Entries: []Entry{
+				{Key: kept.Key, Time: kept.Time, Version: "12.0.3", Edition: "ee", Verdict: VerdictKeep},
+				{Key: nonBackup.Key, Time: modTime, Size: 5, ModTime: modTime, MD5: "01", Verdict: VerdictPrune},
+				{Key: unparsable.Key, Time: modTime, Size: 3, ModTime: modTime, Verdict: VerdictPrune},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		entries := Entries(test.Result)
+		if len(entries) != len(test.Entries) {
+			t.Fatalf("test %d expected %d entries, got %v", i, len(test.Entries), entries)
+		}
+		for j, entry := range test.Entries {
+			if entries[j] != entry {
+				t.Errorf("test %d expected entry %d to be %+v, got %+v", i, j, entry, entries[j])
+			}
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := []Entry{
+		{
+			Key:     "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			Time:    time.Unix(1565056820, 0).UTC(),
+			Version: "12.0.3",
+			Edition: "ee",
+			Size:    10,
+			ModTime: time.Unix(1565056830, 0).UTC(),
+			MD5:     "abcd",
+			Verdict: VerdictKeep,
+		},
+		{
+			Key:     "notes, old.txt",
+			Time:    time.Unix(1565056820, 0).UTC(),
+			ModTime: time.Unix(1565056820, 0).UTC(),
+			Verdict: VerdictPrune,
+		},
+	}
+
+	tests := []struct {
+		Output     string
+		Expected   string
+		ShouldFail bool
+	}{
+		{
+			Output: OutputCSV,
+			Expected: "key,time,version,edition,size,mod_time,md5,verdict\n" +
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar,2019-08-06T02:00:20Z,12.0.3,ee,10,2019-08-06T02:00:30Z,abcd,keep\n" +
+				"\"notes, old.txt\",2019-08-06T02:00:20Z,,,0,2019-08-06T02:00:20Z,,prune\n",
+		},
+		{
+			Output: OutputJSON,
+			Expected: `[
+  {
+    "key": "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+    "time": "2019-08-06T02:00:20Z",
+    "version": "12.0.3",
+    "edition": "ee",
+    "size": 10,
+    "mod_time": "2019-08-06T02:00:30Z",
+    "md5": "abcd",
+    "verdict": "keep"
+  },
+  {
+    "key": "notes, old.txt",
+    "time": "2019-08-06T02:00:20Z",
+    "version": "",
+    "edition": "",
+    "size": 0,
+    "mod_time": "2019-08-06T02:00:20Z",
+    "md5": "",
+    "verdict": "prune"
+  }
+]
+`,
+		},
+		{
+			Output: OutputTable,
+			Expected: "KEY                                                TIME                  VERSION  EDITION  SIZE  VERDICT\n" +
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar  2019-08-06T02:00:20Z  12.0.3   ee       10    keep\n" +
+				"notes, old.txt                                     2019-08-06T02:00:20Z                    0     prune\n",
+		},
+		{
+			Output:     "yaml",
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		buf := &bytes.Buffer{}
+		err := write(buf, test.Output, entries)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if !test.ShouldFail && buf.String() != test.Expected {
+			t.Errorf("test %d expected output:\n%s\ngot:\n%s", i, test.Expected, buf.String())
+		}
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	tests := []struct {
+		Output     string
+		ShouldFail bool
+	}{
+		{Output: OutputCSV},
+		{Output: OutputJSON},
+		{Output: OutputTable},
+		{Output: "bogus", ShouldFail: true},
+		{Output: "", ShouldFail: true},
+	}
+
+	for i, test := range tests {
+		err := checkOutput(test.Output)
+		if err == nil && test.ShouldFail {
+			t.Errorf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Errorf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
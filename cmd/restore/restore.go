@@ -0,0 +1,73 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+)
+
+const (
+	FlagSince = "since"
+)
+
+func NewCmdRestore() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "restore [key...]",
+		Short:         "move quarantined backups back into the bucket",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          restore,
+	}
+	flags.AddJobFlag(cmd)
+	cmd.Flags().String(FlagSince, "", "restore everything quarantined since an RFC3339 time or a duration ago, such as 24h")
+	return cmd
+}
+
+func restore(cmd *cobra.Command, a []string) error {
+	rawSince, err := cmd.Flags().GetString(FlagSince)
+	if err != nil {
+		return fmt.Errorf("missing flag since: %v", err)
+	}
+	if (len(a) == 0) == (rawSince == "") {
+		return errors.New("restore requires either backup keys or --since")
+	}
+
+	var since time.Time
+	if rawSince != "" {
+		since, err = parseSince(rawSince, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, conf, err := flags.BuildJobConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	restored, err := janitor.Restore(ctx, conf, a, since)
+	w := cmd.OutOrStdout()
+	for _, key := range restored {
+		fmt.Fprintf(w, "restored %s\n", key)
+	}
+	if err == nil && len(restored) == 0 {
+		fmt.Fprintln(w, "nothing to restore")
+	}
+	return err
+}
+
+func parseSince(raw string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	ago, err := time.ParseDuration(raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be an RFC3339 time or a duration: %s", raw)
+	}
+	return now.Add(-ago), nil
+}
@@ -2,69 +2,54 @@ package run
 
 import (
 	"fmt"
-	"log"
 
 	"github.com/spf13/cobra"
 
 	"github.com/tlmiller/gitlab-janitor/cmd/flags"
 	"github.com/tlmiller/gitlab-janitor/pkg/config"
-	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+	"github.com/tlmiller/gitlab-janitor/pkg/metrics"
 )
 
 func NewCmdRun() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:           "run",
-		Short:         "run janitor job once",
+		Short:         "run janitor jobs once",
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		RunE:          run,
 	}
+	flags.AddJobFlag(cmd)
 	return cmd
 }
 
 func run(cmd *cobra.Command, a []string) error {
-	builder := config.NewBuilder()
-	flags.BindFlags(cmd, builder.Viper)
-	confFile, err := cmd.Flags().GetString(flags.Config)
+	job, err := cmd.Flags().GetString(flags.Job)
 	if err != nil {
-		return fmt.Errorf("missing flag config: %v", err)
-	}
-
-	var (
-		conf *config.Config
-	)
-	if confFile != "" {
-		conf, err = builder.BuildWithConfFile(confFile)
-	} else {
-		conf, err = builder.Build()
+		return fmt.Errorf("missing flag job: %v", err)
 	}
 
+	ctx, conf, err := flags.BuildConfig(cmd)
 	if err != nil {
-		return fmt.Errorf("building config: %v", err)
+		return err
 	}
 
-	bucket, err := config.ToBucket(conf.Bucket)
+	jobs, _, err := config.ToJobConfigs(conf, job)
 	if err != nil {
-		return fmt.Errorf("getting backup bucket: %v", err)
+		return err
 	}
 
-	decider, err := config.ToDecider(conf.Decider)
-	if err != nil {
-		return fmt.Errorf("getting backup decider: %v", err)
+	var ms map[string]*metrics.Metrics
+	if conf.Metrics.Textfile != "" {
+		ms = metrics.NewJobs(jobs)
 	}
 
-	pruneList, err := backup.CreatePruneList(bucket, decider)
-	if err != nil {
-		return fmt.Errorf("generating backup prune list: %v", err)
-	}
-
-	if !conf.DryRun {
-		log.Printf("deleting %d backups", len(pruneList))
-		if err := backup.DeletePruneList(bucket, pruneList); err != nil {
-			return fmt.Errorf("deleting backups: %v", err)
+	_, err = janitor.RunJobs(ctx, conf, job, ms)
+	if ms != nil {
+		if werr := ms[jobs[0]].WriteTextfile(conf.Metrics.Textfile); werr != nil {
+			logging.Default().Errorf("writing metrics textfile: %v", werr)
 		}
 	}
-
-	defer bucket.Close()
-	return nil
+	return err
 }
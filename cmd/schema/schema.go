@@ -0,0 +1,26 @@
+package schema
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+)
+
+func NewCmdSchema() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "schema",
+		Short:         "print a JSON Schema of the config file for editors",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          schema,
+	}
+	return cmd
+}
+
+func schema(cmd *cobra.Command, a []string) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(config.Schema())
+}
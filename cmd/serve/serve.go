@@ -0,0 +1,49 @@
+package serve
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/janitor"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+func NewCmdServe() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "serve",
+		Short:         "run janitor jobs on the configured schedule",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          serve,
+	}
+	return cmd
+}
+
+func serve(cmd *cobra.Command, a []string) error {
+	_, conf, err := flags.BuildConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	daemon, err := janitor.NewDaemon(conf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		logging.Default().Infof("received %s, shutting down", sig)
+		cancel()
+	}()
+
+	return daemon.Serve(ctx)
+}
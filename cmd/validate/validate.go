@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tlmiller/gitlab-janitor/cmd/flags"
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+)
+
+func NewCmdValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "validate",
+		Short:         "check the config is valid without touching any bucket",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE:          validate,
+	}
+	return cmd
+}
+
+func validate(cmd *cobra.Command, a []string) error {
+	_, conf, err := flags.BuildConfig(cmd)
+	if err != nil {
+		return err
+	}
+	if err := config.Validate(conf); err != nil {
+		return fmt.Errorf("invalid config: %v", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "ok: config is valid")
+	return nil
+}
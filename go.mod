@@ -5,6 +5,8 @@ go 1.12
 require (
 	github.com/hashicorp/go-version v1.2.0
 	github.com/mitchellh/mapstructure v1.1.2
+	github.com/prometheus/client_golang v1.1.0
+	github.com/robfig/cron/v3 v3.0.0
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/viper v1.3.2
 	gocloud.dev v0.16.0
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/tlmiller/gitlab-janitor/cmd"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
 )
 
 func main() {
 	if err := cmd.New().Execute(); err != nil {
-		fmt.Println(err)
+		logging.Default().Errorf("%v", err)
+		if coded, ok := err.(interface{ ExitCode() int }); ok {
+			os.Exit(coded.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
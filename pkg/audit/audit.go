@@ -0,0 +1,215 @@
+package audit
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+const (
+	ActionDelete = "delete"
+	ActionMove   = "move"
+	ActionPurge  = "purge"
+)
+
+// Record is a single line of the audit log describing the removal of one
+// object from the bucket.
+type Record struct {
+	Time       time.Time  `json:"time"`
+	RunID      string     `json:"run_id"`
+	Job        string     `json:"job,omitempty"`
+	Action     string     `json:"action"`
+	Key        string     `json:"key"`
+	BackupTime *time.Time `json:"backup_time,omitempty"`
+	Version    string     `json:"version,omitempty"`
+	Edition    string     `json:"edition,omitempty"`
+	Size       int64      `json:"size"`
+	MD5        string     `json:"md5,omitempty"`
+	Reason     string     `json:"reason"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Log appends records as JSON lines. All methods are safe to call on a nil
+// *Log so callers without an audit log configured need no checks.
+type Log struct {
+	Job   string
+	RunID string
+
+	lock    sync.Mutex
+	enc     *json.Encoder
+	close   func() error
+	err     error
+	records map[string]Record
+	written int
+}
+
+// New creates a log writing to w, which is not closed with the log.
+func New(w io.Writer, job, runID string) *Log {
+	return &Log{
+		Job:     job,
+		RunID:   runID,
+		enc:     json.NewEncoder(w),
+		close:   func() error { return nil },
+		records: map[string]Record{},
+	}
+}
+
+// NewFile creates a log appending to the file at path.
+func NewFile(path, job, runID string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %v", err)
+	}
+	l := New(f, job, runID)
+	l.close = f.Close
+	return l, nil
+}
+
+// BlobCloseTimeout bounds how long writing a blob log may take once the log
+// is closed.
+var BlobCloseTimeout = time.Minute
+
+// NewBlob creates a log written to key in bucket. Blobs cannot be appended
+// to so each log is its own object, written once the log is closed and only
+// when something was recorded. The write is not tied to the run context so
+// the log is kept when a run is cancelled or times out part way. The bucket
+// is closed with the log.
+func NewBlob(bucket *blob.Bucket, key, job, runID string) (*Log, error) {
+	// Cancelling the writer context before closing discards the write.
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := bucket.NewWriter(ctx, key, &blob.WriterOptions{
+		ContentType: "application/x-ndjson",
+	})
+	if err != nil {
+		cancel()
+		bucket.Close()
+		return nil, fmt.Errorf("opening audit log: %v", err)
+	}
+	l := New(w, job, runID)
+	l.close = func() error {
+		defer cancel()
+		if l.written == 0 {
+			cancel()
+			w.Close()
+			return bucket.Close()
+		}
+		done := make(chan error, 1)
+		go func() {
+			done <- w.Close()
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-time.After(BlobCloseTimeout):
+			cancel()
+			err = fmt.Errorf("writing blob timed out after %s: %v", BlobCloseTimeout, <-done)
+		}
+		if cerr := bucket.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	return l, nil
+}
+
+// Prepare records the details of everything in the prune result so they can
+// be logged by key once the object is removed.
+func (l *Log) Prepare(result *backup.PruneResult) {
+	if l == nil {
+		return
+	}
+
+	records := map[string]Record{}
+	for _, b := range result.Backups {
+		r := Record{
+			Key:     b.Key,
+			Edition: b.Edition,
+			Size:    b.Size,
+			MD5:     b.MD5Hex(),
+		}
+		t := b.Time
+		r.BackupTime = &t
+		if b.Version != nil {
+			r.Version = b.Version.Original()
+		}
+		if reason := result.Verdicts[b].Reason; reason != nil {
+			r.Reason = reason.String()
+		}
+		records[b.Key] = r
+	}
+	for _, obj := range result.Unparsable {
+		records[obj.Key] = objectRecord(&obj.Object, fmt.Sprintf("unparsable backup (%v)", obj.Err))
+	}
+	for _, obj := range result.NonBackup {
+		records[obj.Key] = objectRecord(obj, "object is not a backup")
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.records = records
+}
+
+func objectRecord(obj *backup.Object, reason string) Record {
+	return Record{
+		Key:    obj.Key,
+		Size:   obj.Size,
+		MD5:    hex.EncodeToString(obj.MD5),
+		Reason: reason,
+	}
+}
+
+// Record logs the removal of key with any details from the last prepared
+// prune result.
+func (l *Log) Record(action, key string, err error) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	r, exists := l.records[key]
+	if !exists {
+		r = Record{Key: key}
+	}
+	if action == ActionPurge {
+		r.Reason = "quarantine grace period passed"
+	}
+	r.Time = time.Now().UTC()
+	r.RunID = l.RunID
+	r.Job = l.Job
+	r.Action = action
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if werr := l.enc.Encode(&r); werr != nil && l.err == nil {
+		l.err = fmt.Errorf("writing audit log: %v", werr)
+	}
+	l.written++
+}
+
+// Close closes the log returning the first error writing to it.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	err := l.close()
+	if l.err != nil {
+		return l.err
+	} else if err != nil {
+		return fmt.Errorf("closing audit log: %v", err)
+	}
+	return nil
+}
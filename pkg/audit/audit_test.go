@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+func TestLogRecord(t *testing.T) {
+	b, err := backup.WithGitLabKeyParser().Parse("1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing backup key: %v", err)
+	}
+	b.Size = 10
+	result := &backup.PruneResult{
+		Listing: backup.Listing{
+			Backups:   []*backup.Backup{b},
+			NonBackup: []*backup.Object{{Key: "notes.txt", Size: 5}},
+		},
+		Verdicts: map[*backup.Backup]backup.Verdict{
+			b: {Reason: &backup.Reason{Message: "not in the last 1"}},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	l := New(buf, "prod", "abc123")
+	l.Prepare(result)
+	l.Record(ActionDelete, b.Key, nil)
+	l.Record(ActionDelete, "notes.txt", errors.New("access denied"))
+	l.Record(ActionPurge, "quarantine/old.tar", nil)
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error closing log: %v", err)
+	}
+
+	tests := []Record{
+		{
+			Job:        "prod",
+			RunID:      "abc123",
+			Action:     ActionDelete,
+			Key:        b.Key,
+			BackupTime: &b.Time,
+			Version:    "12.0.3",
+			Edition:    "ee",
+			Size:       10,
+			Reason:     "pruned: not in the last 1",
+		},
+		{
+			Job:    "prod",
+			RunID:  "abc123",
+			Action: ActionDelete,
+			Key:    "notes.txt",
+			Size:   5,
+			Reason: "object is not a backup",
+			Error:  "access denied",
+		},
+		{
+			Job:    "prod",
+			RunID:  "abc123",
+			Action: ActionPurge,
+			Key:    "quarantine/old.tar",
+			Reason: "quarantine grace period passed",
+		},
+	}
+
+	scanner := bufio.NewScanner(buf)
+	for i, test := range tests {
+		if !scanner.Scan() {
+			t.Fatalf("expected audit log line %d", i)
+		}
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("unexpected error decoding audit log line %d: %v", i, err)
+		}
+		if r.Time.IsZero() || time.Since(r.Time) > time.Minute {
+			t.Errorf("test %d expected a recent time, got %s", i, r.Time)
+		}
+		if (r.BackupTime == nil) != (test.BackupTime == nil) ||
+			(r.BackupTime != nil && !r.BackupTime.Equal(*test.BackupTime)) {
+			t.Errorf("test %d expected backup time %v, got %v", i, test.BackupTime, r.BackupTime)
+		}
+		r.Time, r.BackupTime, test.BackupTime = time.Time{}, nil, nil
+		if r != test {
+			t.Errorf("test %d expected record %+v, got %+v", i, test, r)
+		}
+	}
+	if scanner.Scan() {
+		t.Errorf("unexpected extra audit log line: %s", scanner.Text())
+	}
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	l.Prepare(&backup.PruneResult{})
+	l.Record(ActionDelete, "key", nil)
+	if err := l.Close(); err != nil {
+		t.Errorf("unexpected error closing nil log: %v", err)
+	}
+}
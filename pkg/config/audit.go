@@ -0,0 +1,80 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+)
+
+const (
+	// AuditFileStdout writes the audit log to standard output.
+	AuditFileStdout = "-"
+)
+
+// Audit is where the record of every removed object is written, either a
+// file that is appended to or a bucket with one object per run.
+type Audit struct {
+	File   string `json:"file" yaml:"file" mapstructure:"file"`
+	URL    string `json:"url" yaml:"url" mapstructure:"url"`
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+}
+
+func NewAudit() *Audit {
+	return &Audit{}
+}
+
+// ToAuditLog opens the configured audit log for a run, returning nil when
+// no audit log is configured.
+func ToAuditLog(ctx context.Context, conf *Config, runID string) (*audit.Log, error) {
+	if err := validateAudit(conf.Audit); err != nil {
+		return nil, err
+	}
+	if err := validateAuditInBucket(conf); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case conf.Audit.File == AuditFileStdout:
+		return audit.New(os.Stdout, conf.Job, runID), nil
+	case conf.Audit.File != "":
+		return audit.NewFile(conf.Audit.File, conf.Job, runID)
+	case conf.Audit.URL != "":
+		bucket, err := ToBucketWithContext(ctx, &Bucket{URL: conf.Audit.URL})
+		if err != nil {
+			return nil, fmt.Errorf("getting audit bucket: %v", err)
+		}
+		key := conf.Audit.Prefix + time.Now().UTC().Format("20060102T150405Z") + "-"
+		if conf.Job != "" {
+			key += conf.Job + "-"
+		}
+		return audit.NewBlob(bucket, key+runID+".jsonl", conf.Job, runID)
+	}
+	return nil, nil
+}
+
+// AuditInBucket reports whether the audit log is written to the bucket being
+// cleaned, in which case it is left out of listings.
+func AuditInBucket(conf *Config) bool {
+	return conf.Audit.URL != "" && conf.Audit.URL == conf.Bucket.URL
+}
+
+func validateAuditInBucket(conf *Config) error {
+	if AuditInBucket(conf) && conf.Audit.Prefix == "" {
+		return errors.New("audit prefix cannot be empty when auditing to the same bucket")
+	}
+	return nil
+}
+
+func validateAudit(conf *Audit) error {
+	if conf.File != "" && conf.URL != "" {
+		return errors.New("audit log can be written to a file or url but not both")
+	}
+	if conf.URL != "" {
+		return withPath("url", validateURL(conf.URL))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+)
+
+func TestToAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("unexpected error creating audit directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Audit      *Audit
+		Enabled    bool
+		ShouldFail bool
+	}{
+		{
+			Audit: NewAudit(),
+		},
+		{
+			Audit:   &Audit{File: AuditFileStdout},
+			Enabled: true,
+		},
+		{
+			Audit:   &Audit{File: filepath.Join(dir, "audit.jsonl")},
+			Enabled: true,
+		},
+		{
+			Audit:   &Audit{URL: "file://" + dir, Prefix: "audit/"},
+			Enabled: true,
+		},
+		{
+			Audit:      &Audit{File: AuditFileStdout, URL: "mem://"},
+			ShouldFail: true,
+		},
+		{
+			Audit:      &Audit{File: filepath.Join(dir, "missing", "audit.jsonl")},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		conf := New()
+		conf.Job = "prod"
+		conf.Audit = test.Audit
+
+		l, err := ToAuditLog(context.Background(), conf, "abc123")
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if enabled := l != nil; enabled != test.Enabled {
+			t.Errorf("test %d expected audit log enabled %t, got %t", i, test.Enabled, enabled)
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("unexpected error closing audit log for test %d: %v", i, err)
+		}
+	}
+
+	// Nothing was recorded so no object is written to the bucket.
+	matches, err := filepath.Glob(filepath.Join(dir, "audit", "*"))
+	if err != nil || len(matches) != 0 {
+		t.Errorf("expected no audit log object for an empty log, got %v", matches)
+	}
+}
+
+func TestToAuditLogOutlivesRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("unexpected error creating audit directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := New()
+	conf.Audit = &Audit{URL: "file://" + dir}
+	ctx, cancel := context.WithCancel(context.Background())
+	l, err := ToAuditLog(ctx, conf, "abc123")
+	if err != nil {
+		t.Fatalf("unexpected error opening audit log: %v", err)
+	}
+	l.Record(audit.ActionDelete, "1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar", nil)
+	cancel()
+	if err := l.Close(); err != nil {
+		t.Fatalf("unexpected error closing audit log after the run was cancelled: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*-abc123.jsonl"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected audit log object to be written, got %v", matches)
+	}
+	data, err := ioutil.ReadFile(matches[0])
+	if err != nil || !strings.Contains(string(data), "1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar") {
+		t.Errorf("expected audit log to hold the deletion, got %q: %v", data, err)
+	}
+}
+
+func TestAuditInBucket(t *testing.T) {
+	tests := []struct {
+		Audit    *Audit
+		Excluded []string
+	}{
+		{
+			Audit: NewAudit(),
+		},
+		{
+			Audit: &Audit{File: AuditFileStdout},
+		},
+		{
+			Audit: &Audit{URL: "file:///audit", Prefix: "audit/"},
+		},
+		{
+			Audit:    &Audit{URL: "file:///backups", Prefix: "audit/"},
+			Excluded: []string{"audit/"},
+		},
+	}
+
+	for i, test := range tests {
+		conf := New()
+		conf.Bucket.URL = "file:///backups"
+		conf.Audit = test.Audit
+
+		exclude := ToJobScope(conf).Exclude
+		if len(exclude) != len(test.Excluded) || (len(exclude) > 0 && exclude[0] != test.Excluded[0]) {
+			t.Errorf("test %d expected excluded %v, got %v", i, test.Excluded, exclude)
+		}
+	}
+}
@@ -3,16 +3,28 @@ package config
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
 	_ "gocloud.dev/blob/gcsblob"
 	_ "gocloud.dev/blob/s3blob"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
 )
 
 type Bucket struct {
-	URL string `json:"url" yaml:"url"`
+	URL string `json:"url" yaml:"url" mapstructure:"url"`
+	// Prefix limits listing and deletion to keys under it, the delimiter
+	// being added when it does not end in one. Key parsers see keys with
+	// the prefix removed.
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	// Delimiter separates levels of keys when not recursive, defaulting to /.
+	Delimiter string `json:"delimiter" yaml:"delimiter" mapstructure:"delimiter"`
+	// Recursive lists every object under the prefix rather than only those
+	// directly under it. Defaults to true.
+	Recursive *bool `json:"recursive" yaml:"recursive" mapstructure:"recursive"`
 }
 
 func NewBucket() *Bucket {
@@ -30,3 +42,29 @@ func ToBucketWithContext(ctx context.Context, conf *Bucket) (*blob.Bucket, error
 
 	return blob.OpenBucket(ctx, conf.URL)
 }
+
+// ToPrefix is the bucket prefix ending in the delimiter, so a prefix of prod
+// does not also take in prod-old/ or production/.
+func ToPrefix(conf *Bucket) string {
+	delimiter := conf.Delimiter
+	if delimiter == "" {
+		delimiter = "/"
+	}
+	if conf.Prefix == "" || strings.HasSuffix(conf.Prefix, delimiter) {
+		return conf.Prefix
+	}
+	return conf.Prefix + delimiter
+}
+
+func ToScope(conf *Bucket) backup.Scope {
+	scope := backup.Scope{
+		Prefix: ToPrefix(conf),
+	}
+	if conf.Recursive != nil && !*conf.Recursive {
+		scope.Delimiter = conf.Delimiter
+		if scope.Delimiter == "" {
+			scope.Delimiter = "/"
+		}
+	}
+	return scope
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestToScope(t *testing.T) {
+	recursive, flat := true, false
+	tests := []struct {
+		Config    *Bucket
+		Prefix    string
+		Delimiter string
+	}{
+		{
+			Config: &Bucket{Prefix: "gitlab-prod/"},
+			Prefix: "gitlab-prod/",
+		},
+		{
+			Config: &Bucket{Prefix: "gitlab-prod/", Delimiter: "/", Recursive: &recursive},
+			Prefix: "gitlab-prod/",
+		},
+		{
+			Config:    &Bucket{Prefix: "gitlab-prod/", Recursive: &flat},
+			Prefix:    "gitlab-prod/",
+			Delimiter: "/",
+		},
+		{
+			Config:    &Bucket{Delimiter: ":", Recursive: &flat},
+			Delimiter: ":",
+		},
+		{
+			Config: &Bucket{Prefix: "prod"},
+			Prefix: "prod/",
+		},
+		{
+			Config:    &Bucket{Prefix: "prod", Delimiter: ":", Recursive: &flat},
+			Prefix:    "prod:",
+			Delimiter: ":",
+		},
+	}
+
+	for i, test := range tests {
+		scope := ToScope(test.Config)
+		if scope.Prefix != test.Prefix || scope.Delimiter != test.Delimiter {
+			t.Errorf("test %d scope %+v does not match prefix %q delimiter %q",
+				i, scope, test.Prefix, test.Delimiter)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+type Check struct {
+	MaxAge       string  `json:"max_age" yaml:"maxAge" mapstructure:"maxAge"`
+	Interval     string  `json:"interval" yaml:"interval" mapstructure:"interval"`
+	GapWindow    string  `json:"gap_window" yaml:"gapWindow" mapstructure:"gapWindow"`
+	MinSizeRatio float64 `json:"min_size_ratio" yaml:"minSizeRatio" mapstructure:"minSizeRatio"`
+}
+
+func NewCheck() *Check {
+	return &Check{}
+}
+
+func ToCheckOptions(conf *Check) (backup.CheckOptions, error) {
+	opts := backup.CheckOptions{}
+	durations := []struct {
+		name  string
+		raw   string
+		value *time.Duration
+	}{
+		{"max age", conf.MaxAge, &opts.MaxAge},
+		{"interval", conf.Interval, &opts.Interval},
+		{"gap window", conf.GapWindow, &opts.GapWindow},
+	}
+	for _, d := range durations {
+		if d.raw == "" {
+			continue
+		}
+		value, err := time.ParseDuration(d.raw)
+		if err != nil {
+			return opts, fmt.Errorf("parsing check %s: %v", d.name, err)
+		}
+		if value < time.Duration(0) {
+			return opts, fmt.Errorf("check %s cannot be less than zero", d.name)
+		}
+		*d.value = value
+	}
+
+	if conf.MinSizeRatio < 0 || conf.MinSizeRatio > 1 {
+		return opts, errors.New("check min size ratio must be between 0 and 1")
+	}
+	opts.MinSizeRatio = conf.MinSizeRatio
+
+	if opts.MaxAge == 0 && opts.Interval == 0 && opts.MinSizeRatio == 0 {
+		return opts, errors.New("check requires at least one of max age, interval or min size ratio")
+	}
+	return opts, nil
+}
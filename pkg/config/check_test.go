@@ -0,0 +1,46 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestCheckOptions(t *testing.T) {
+	tests := []struct {
+		Config     *Check
+		ShouldFail bool
+	}{
+		{
+			Config:     &Check{MaxAge: "26h"},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Check{Interval: "24h", GapWindow: "168h", MinSizeRatio: 0.5},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Check{},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Check{MaxAge: "-1h"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Check{Interval: "daily"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Check{MinSizeRatio: 1.5},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToCheckOptions(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
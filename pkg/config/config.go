@@ -1,7 +1,10 @@
 package config
 
 import (
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
 )
 
 type Builder struct {
@@ -9,9 +12,25 @@ type Builder struct {
 }
 
 type Config struct {
-	Bucket  *Bucket  `json:"bucket" yaml:"bucket"`
-	Decider *Decider `json:"decider" yaml:"decider"`
-	DryRun  bool     `json:"dry_run" yaml:"dryRun"`
+	Audit            *Audit        `json:"audit" yaml:"audit" mapstructure:"audit"`
+	Bucket           *Bucket       `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+	Check            *Check        `json:"check" yaml:"check" mapstructure:"check"`
+	Decider          *Decider      `json:"decider" yaml:"decider" mapstructure:"decider"`
+	Delete           *Delete       `json:"delete" yaml:"delete" mapstructure:"delete"`
+	DryRun           bool          `json:"dry_run" yaml:"dryRun" mapstructure:"dryRun"`
+	Job              string        `json:"-" yaml:"-" mapstructure:"-"`
+	Jobs             []*Job        `json:"jobs" yaml:"jobs" mapstructure:"jobs"`
+	Log              *Log          `json:"log" yaml:"log" mapstructure:"log"`
+	MaxDeleteCount   int           `json:"max_delete_count" yaml:"maxDeleteCount" mapstructure:"maxDeleteCount"`
+	MaxDeletePercent float64       `json:"max_delete_percent" yaml:"maxDeletePercent" mapstructure:"maxDeletePercent"`
+	Metrics          *Metrics      `json:"metrics" yaml:"metrics" mapstructure:"metrics"`
+	MinKeep          int           `json:"min_keep" yaml:"minKeep" mapstructure:"minKeep"`
+	Parallel         bool          `json:"parallel" yaml:"parallel" mapstructure:"parallel"`
+	Parser           *Parser       `json:"parser" yaml:"parser" mapstructure:"parser"`
+	Quarantine       *Quarantine   `json:"quarantine" yaml:"quarantine" mapstructure:"quarantine"`
+	Schedule         *Schedule     `json:"schedule" yaml:"schedule" mapstructure:"schedule"`
+	Timeout          string        `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	Unrecognised     *Unrecognised `json:"unrecognised" yaml:"unrecognised" mapstructure:"unrecognised"`
 }
 
 const (
@@ -20,6 +39,7 @@ const (
 )
 
 func (b *Builder) BuildWithConfFile(confFile string) (*Config, error) {
+	logging.Default().Debugf("reading config file %s", confFile)
 	b.Viper.SetConfigFile(confFile)
 	if err := b.Viper.MergeInConfig(); err != nil {
 		return nil, err
@@ -30,14 +50,33 @@ func (b *Builder) BuildWithConfFile(confFile string) (*Config, error) {
 func (b *Builder) Build() (*Config, error) {
 	c := New()
 	b.Viper.AutomaticEnv()
-	return c, b.Viper.Unmarshal(&c)
+	meta := &mapstructure.Metadata{}
+	err := b.Viper.Unmarshal(&c, func(decoderConf *mapstructure.DecoderConfig) {
+		decoderConf.Metadata = meta
+	})
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	if err := unusedError(meta); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 func New() *Config {
 	return &Config{
-		Bucket:  NewBucket(),
-		Decider: NewDecider(),
-		DryRun:  false,
+		Audit:        NewAudit(),
+		Bucket:       NewBucket(),
+		Check:        NewCheck(),
+		Decider:      NewDecider(),
+		Delete:       NewDelete(),
+		DryRun:       false,
+		Log:          NewLog(),
+		Metrics:      NewMetrics(),
+		Parser:       NewParser(),
+		Quarantine:   NewQuarantine(),
+		Schedule:     NewSchedule(),
+		Unrecognised: NewUnrecognised(),
 	}
 }
 
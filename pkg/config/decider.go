@@ -4,14 +4,17 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/mitchellh/mapstructure"
-
 	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
 )
 
 type Decider struct {
-	Type    string                 `json:"type" yaml:"type"`
-	Options map[string]interface{} `json:"options" yaml:"options"`
+	Type    string                 `json:"type" yaml:"type" mapstructure:"type"`
+	Options map[string]interface{} `json:"options" yaml:"options" mapstructure:"options"`
+	// Edition restricts the decider to backups of one edition, pruning the
+	// rest including backups with no edition in their key.
+	Edition string `json:"edition" yaml:"edition" mapstructure:"edition"`
+	// GroupByEdition evaluates the decider separately for each edition.
+	GroupByEdition bool `json:"group_by_edition" yaml:"groupByEdition" mapstructure:"groupByEdition"`
 }
 
 func NewDecider() *Decider {
@@ -19,20 +22,31 @@ func NewDecider() *Decider {
 }
 
 func ToDecider(conf *Decider) (backup.Decider, error) {
-	factory := DeciderMapperFactory()
 	if conf.Type == "" {
-		return nil, errors.New("decider type cannot be null")
+		return nil, &ConfigError{Path: "type", Err: errors.New("decider type cannot be null")}
 	}
 
-	mapping, found := factory[conf.Type]
+	deciderLock.RLock()
+	mapping, found := deciderMappings[conf.Type]
+	deciderLock.RUnlock()
 	if !found {
-		return nil, fmt.Errorf("no decider mapping for type: %s", conf.Type)
+		return nil, &ConfigError{Path: "type", Err: fmt.Errorf("no decider mapping for type: %s", conf.Type)}
 	}
 
 	rawConf := mapping.Config()
-	if err := mapstructure.Decode(conf.Options, rawConf); err != nil {
-		return nil, fmt.Errorf("decoding  decider configuration: %v", err)
+	if err := decodeStrict(conf.Options, rawConf); err != nil {
+		return nil, withPath("options", err)
 	}
 
-	return mapping.Mapper(rawConf)
+	decider, err := mapping.Mapper(rawConf)
+	if err != nil {
+		return nil, withPath("options", err)
+	}
+	if conf.GroupByEdition {
+		decider = backup.WithGroupByEdition(decider)
+	}
+	if conf.Edition != "" {
+		decider = backup.WithEdition(conf.Edition, decider)
+	}
+	return decider, nil
 }
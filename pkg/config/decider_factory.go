@@ -1,6 +1,10 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"sync"
+
 	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
 )
 
@@ -8,36 +12,106 @@ type DeciderMapper func(conf interface{}) (backup.Decider, error)
 
 type DeciderMapperConfig func() interface{}
 
+// DeciderMapping builds a decider type. The decider options are decoded into
+// the value returned by Config, which is then passed to Mapper.
 type DeciderMapping struct {
 	Config DeciderMapperConfig
 	Mapper DeciderMapper
 }
 
+var (
+	deciderLock     sync.RWMutex
+	deciderMappings = map[string]DeciderMapping{}
+)
+
+func init() {
+	mustRegisterDecider("keepAggregateAgree", DeciderMapping{
+		Config: func() interface{} { return &DeciderAggregateAgreeConfig{} },
+		Mapper: deciderAggregateAgreeMapper,
+	})
+	mustRegisterDecider("keepCalendarBuckets", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepCalendarBucketsConfig{} },
+		Mapper: deciderKeepCalendarBucketsMapper,
+	})
+	mustRegisterDecider("keepExpression", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepExpressionConfig{} },
+		Mapper: deciderKeepExpressionMapper,
+	})
+	mustRegisterDecider("keepFirstMatch", DeciderMapping{
+		Config: func() interface{} { return &DeciderFirstKeepMatchConfig{} },
+		Mapper: deciderFirstKeepMatchMapper,
+	})
+	mustRegisterDecider("keepAfterDuration", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepAfterDurationConfig{} },
+		Mapper: deciderKeepAfterDurationMapper,
+	})
+	mustRegisterDecider("keepAfterTime", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepAfterTimeConfig{} },
+		Mapper: deciderKeepAfterTimeMapper,
+	})
+	mustRegisterDecider("keepPerSeries", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepPerSeriesConfig{} },
+		Mapper: deciderKeepPerSeriesMapper,
+	})
+	mustRegisterDecider("keepPerVersion", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepPerVersionConfig{} },
+		Mapper: deciderKeepPerVersionMapper,
+	})
+	mustRegisterDecider("keepNumberVersions", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepNumberOfVersionsConfig{} },
+		Mapper: deciderKeepNumberOfVersionsMapper,
+	})
+	mustRegisterDecider("keepVersionConstraint", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepVersionConstraintConfig{} },
+		Mapper: deciderKeepVersionConstraintMapper,
+	})
+	mustRegisterDecider("keepWithinSize", DeciderMapping{
+		Config: func() interface{} { return &DeciderKeepWithinSizeConfig{} },
+		Mapper: deciderKeepWithinSizeMapper,
+	})
+}
+
+// RegisterDecider adds a decider type that can be used in the config under
+// name. Registering a name twice is an error, including the names of the
+// built in deciders.
+func RegisterDecider(name string, mapping DeciderMapping) error {
+	if name == "" {
+		return errors.New("decider name cannot be null")
+	}
+	if mapping.Config == nil || mapping.Mapper == nil {
+		return fmt.Errorf("decider %s mapping requires a config and mapper", name)
+	}
+
+	deciderLock.Lock()
+	defer deciderLock.Unlock()
+	if _, exists := deciderMappings[name]; exists {
+		return fmt.Errorf("decider %s is already registered", name)
+	}
+	deciderMappings[name] = mapping
+	return nil
+}
+
+func mustRegisterDecider(name string, mapping DeciderMapping) {
+	if err := RegisterDecider(name, mapping); err != nil {
+		panic(err)
+	}
+}
+
+// unregisterDecider removes a registered decider type, letting tests clean
+// up after themselves.
+func unregisterDecider(name string) {
+	deciderLock.Lock()
+	defer deciderLock.Unlock()
+	delete(deciderMappings, name)
+}
+
+// DeciderMapperFactory returns every registered decider mapping by name.
 func DeciderMapperFactory() map[string]DeciderMapping {
-	return map[string]DeciderMapping{
-		"keepAggregateAgree": DeciderMapping{
-			Config: func() interface{} { return &DeciderAggregateAgreeConfig{} },
-			Mapper: deciderAggregateAgreeMapper,
-		},
-		"keepFirstMatch": DeciderMapping{
-			Config: func() interface{} { return &DeciderFirstKeepMatchConfig{} },
-			Mapper: deciderFirstKeepMatchMapper,
-		},
-		"keepAfterDuration": DeciderMapping{
-			Config: func() interface{} { return &DeciderKeepAfterDurationConfig{} },
-			Mapper: deciderKeepAfterDurationMapper,
-		},
-		"keepAfterTime": DeciderMapping{
-			Config: func() interface{} { return &DeciderKeepAfterTimeConfig{} },
-			Mapper: deciderKeepAfterTimeMapper,
-		},
-		"keepPerVersion": DeciderMapping{
-			Config: func() interface{} { return &DeciderKeepPerVersionConfig{} },
-			Mapper: deciderKeepPerVersionMapper,
-		},
-		"keepNumberVersions": DeciderMapping{
-			Config: func() interface{} { return &DeciderKeepNumberOfVersionsConfig{} },
-			Mapper: deciderKeepNumberOfVersionsMapper,
-		},
+	deciderLock.RLock()
+	defer deciderLock.RUnlock()
+	factory := make(map[string]DeciderMapping, len(deciderMappings))
+	for name, mapping := range deciderMappings {
+		factory[name] = mapping
 	}
+	return factory
 }
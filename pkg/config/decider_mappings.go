@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/go-version"
+
 	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
 )
 
@@ -33,6 +35,32 @@ type DeciderKeepNumberOfVersionsConfig struct {
 	Keep int `mapstructure:"keep"`
 }
 
+type DeciderKeepPerSeriesConfig struct {
+	Count  int    `mapstructure:"count"`
+	Series string `mapstructure:"series"`
+}
+
+type DeciderKeepVersionConstraintConfig struct {
+	Constraint string `mapstructure:"constraint"`
+}
+
+type DeciderKeepWithinSizeConfig struct {
+	MaxTotal interface{} `mapstructure:"maxTotal"`
+}
+
+type DeciderKeepExpressionConfig struct {
+	Expression string `mapstructure:"expression"`
+	TimeZone   string `mapstructure:"timeZone"`
+}
+
+type DeciderKeepCalendarBucketsConfig struct {
+	Daily    int    `mapstructure:"daily"`
+	Weekly   int    `mapstructure:"weekly"`
+	Monthly  int    `mapstructure:"monthly"`
+	Yearly   int    `mapstructure:"yearly"`
+	TimeZone string `mapstructure:"timeZone"`
+}
+
 func deciderAggregateAgreeMapper(raw interface{}) (backup.Decider, error) {
 	conf, ok := raw.(*DeciderAggregateAgreeConfig)
 	if !ok {
@@ -51,7 +79,7 @@ func deciderAggregateMapper(conf []Decider) ([]backup.Decider, error) {
 	for i, deciderConf := range conf {
 		decider, err := ToDecider(&deciderConf)
 		if err != nil {
-			return nil, fmt.Errorf("building decider aggregate aggree for decider %d: %v", i, err)
+			return nil, withPath(fmt.Sprintf("deciders[%d]", i), err)
 		}
 		deciders[i] = decider
 	}
@@ -79,10 +107,10 @@ func deciderKeepAfterDurationMapper(raw interface{}) (backup.Decider, error) {
 
 	duration, err := time.ParseDuration(conf.Duration)
 	if err != nil {
-		return nil, fmt.Errorf("parsing decider keep after duration: %v", err)
+		return nil, withPath("duration", fmt.Errorf("parsing decider keep after duration: %v", err))
 	}
 	if duration < time.Duration(0) {
-		return nil, errors.New("decider keep after duration value cannot be less than zero")
+		return nil, withPath("duration", errors.New("decider keep after duration value cannot be less than zero"))
 	}
 	return backup.WithKeepAfterDuration(duration), nil
 }
@@ -95,7 +123,7 @@ func deciderKeepAfterTimeMapper(raw interface{}) (backup.Decider, error) {
 
 	time, err := time.Parse(time.RFC1123Z, conf.Time)
 	if err != nil {
-		return nil, fmt.Errorf("parsing decider keep after time: %v", err)
+		return nil, withPath("time", fmt.Errorf("parsing decider keep after time: %v", err))
 	}
 	return backup.WithKeepAfterTime(time), nil
 }
@@ -106,8 +134,8 @@ func deciderKeepPerVersionMapper(raw interface{}) (backup.Decider, error) {
 		return nil, errors.New("decider keep per version config is not of type DeciderKeepPerVersionConfig")
 	}
 
-	if conf.Count < 0 {
-		return nil, errors.New("decider keep per versions cannot be less than zero ")
+	if conf.Count < 1 {
+		return nil, withPath("count", errors.New("decider keep per versions cannot be less than one"))
 	}
 	return backup.WithKeepPerVersion(conf.Count), nil
 }
@@ -119,7 +147,91 @@ func deciderKeepNumberOfVersionsMapper(raw interface{}) (backup.Decider, error)
 	}
 
 	if conf.Keep < 1 {
-		return nil, errors.New("decider keep number of versions cannot be less than one")
+		return nil, withPath("keep", errors.New("decider keep number of versions cannot be less than one"))
 	}
 	return backup.WithKeepNumberOfVersions(conf.Keep), nil
 }
+
+func deciderKeepCalendarBucketsMapper(raw interface{}) (backup.Decider, error) {
+	conf, ok := raw.(*DeciderKeepCalendarBucketsConfig)
+	if !ok {
+		return nil, errors.New("decider keep calendar buckets config is not of type DeciderKeepCalendarBucketsConfig")
+	}
+
+	if conf.Daily < 0 || conf.Weekly < 0 || conf.Monthly < 0 || conf.Yearly < 0 {
+		return nil, errors.New("decider keep calendar buckets counts cannot be less than zero")
+	}
+	if conf.Daily+conf.Weekly+conf.Monthly+conf.Yearly == 0 {
+		return nil, errors.New("decider keep calendar buckets requires at least one daily, weekly, monthly or yearly count")
+	}
+
+	loc, err := time.LoadLocation(conf.TimeZone)
+	if err != nil {
+		return nil, withPath("timeZone", fmt.Errorf("parsing decider keep calendar buckets time zone: %v", err))
+	}
+	return backup.WithKeepCalendarBuckets(conf.Daily, conf.Weekly, conf.Monthly, conf.Yearly, loc), nil
+}
+
+func deciderKeepWithinSizeMapper(raw interface{}) (backup.Decider, error) {
+	conf, ok := raw.(*DeciderKeepWithinSizeConfig)
+	if !ok {
+		return nil, errors.New("decider keep within size config is not of type DeciderKeepWithinSizeConfig")
+	}
+
+	maxTotal, err := parseByteSize(conf.MaxTotal)
+	if err != nil {
+		return nil, withPath("maxTotal", fmt.Errorf("parsing decider keep within size max total: %v", err))
+	}
+	return backup.WithKeepWithinSize(maxTotal), nil
+}
+
+func deciderKeepPerSeriesMapper(raw interface{}) (backup.Decider, error) {
+	conf, ok := raw.(*DeciderKeepPerSeriesConfig)
+	if !ok {
+		return nil, errors.New("decider keep per series config is not of type DeciderKeepPerSeriesConfig")
+	}
+
+	if conf.Count < 1 {
+		return nil, withPath("count", errors.New("decider keep per series count cannot be less than one"))
+	}
+	switch conf.Series {
+	case "major":
+		return backup.WithKeepPerSeries(conf.Count, backup.SeriesMajor), nil
+	case "", "minor":
+		return backup.WithKeepPerSeries(conf.Count, backup.SeriesMinor), nil
+	}
+	return nil, withPath("series", fmt.Errorf("decider keep per series unknown series %s, must be major or minor", conf.Series))
+}
+
+func deciderKeepVersionConstraintMapper(raw interface{}) (backup.Decider, error) {
+	conf, ok := raw.(*DeciderKeepVersionConstraintConfig)
+	if !ok {
+		return nil, errors.New("decider keep version constraint config is not of type DeciderKeepVersionConstraintConfig")
+	}
+
+	constraints, err := version.NewConstraint(conf.Constraint)
+	if err != nil {
+		return nil, withPath("constraint", fmt.Errorf("parsing decider keep version constraint: %v", err))
+	}
+	return backup.WithKeepVersionConstraint(constraints), nil
+}
+
+func deciderKeepExpressionMapper(raw interface{}) (backup.Decider, error) {
+	conf, ok := raw.(*DeciderKeepExpressionConfig)
+	if !ok {
+		return nil, errors.New("decider keep expression config is not of type DeciderKeepExpressionConfig")
+	}
+
+	if conf.Expression == "" {
+		return nil, withPath("expression", errors.New("decider keep expression cannot be null"))
+	}
+	expr, err := backup.ParseExpression(conf.Expression)
+	if err != nil {
+		return nil, withPath("expression", err)
+	}
+	loc, err := time.LoadLocation(conf.TimeZone)
+	if err != nil {
+		return nil, withPath("timeZone", fmt.Errorf("parsing decider keep expression time zone: %v", err))
+	}
+	return backup.WithKeepExpression(expr, loc), nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"testing"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
 )
 
 func TestEmptyDeciderTypeFails(t *testing.T) {
@@ -76,3 +78,359 @@ func TestDeciderKeepDuration(t *testing.T) {
 		}
 	}
 }
+
+func TestDeciderKeepCalendarBuckets(t *testing.T) {
+	tests := []struct {
+		Config     *Decider
+		ShouldFail bool
+	}{
+		{
+			Config: &Decider{
+				Type: "keepCalendarBuckets",
+				Options: map[string]interface{}{
+					"daily":   7,
+					"weekly":  4,
+					"monthly": 12,
+					"yearly":  3,
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type: "keepCalendarBuckets",
+				Options: map[string]interface{}{
+					"weekly":   4,
+					"timeZone": "UTC",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type:    "keepCalendarBuckets",
+				Options: map[string]interface{}{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepCalendarBuckets",
+				Options: map[string]interface{}{
+					"daily": -1,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepCalendarBuckets",
+				Options: map[string]interface{}{
+					"daily":    7,
+					"timeZone": "Not/AZone",
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToDecider(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+func TestDeciderKeepWithinSize(t *testing.T) {
+	tests := []struct {
+		Config     *Decider
+		ShouldFail bool
+	}{
+		{
+			Config: &Decider{
+				Type: "keepWithinSize",
+				Options: map[string]interface{}{
+					"maxTotal": "500GiB",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type: "keepWithinSize",
+				Options: map[string]interface{}{
+					"maxTotal": 1073741824,
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type:    "keepWithinSize",
+				Options: map[string]interface{}{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepWithinSize",
+				Options: map[string]interface{}{
+					"maxTotal": "lots",
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToDecider(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+func TestDeciderKeepVersionSeries(t *testing.T) {
+	tests := []struct {
+		Config     *Decider
+		ShouldFail bool
+	}{
+		{
+			Config: &Decider{
+				Type: "keepVersionConstraint",
+				Options: map[string]interface{}{
+					"constraint": ">= 12.0, < 13",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type: "keepVersionConstraint",
+				Options: map[string]interface{}{
+					"constraint": "about 12",
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type:    "keepVersionConstraint",
+				Options: map[string]interface{}{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepPerSeries",
+				Options: map[string]interface{}{
+					"count":  2,
+					"series": "minor",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type: "keepPerSeries",
+				Options: map[string]interface{}{
+					"count":  1,
+					"series": "major",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type: "keepPerSeries",
+				Options: map[string]interface{}{
+					"count":  1,
+					"series": "patch",
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepPerSeries",
+				Options: map[string]interface{}{
+					"count": -1,
+				},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Decider{
+				Type: "keepPerSeries",
+				Options: map[string]interface{}{
+					"count": 0,
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToDecider(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+func TestDeciderEdition(t *testing.T) {
+	tests := []struct {
+		Config     *Decider
+		ShouldFail bool
+	}{
+		{
+			Config: &Decider{
+				Type:    "keepPerVersion",
+				Edition: "ee",
+				Options: map[string]interface{}{
+					"count": 2,
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type:           "keepNumberVersions",
+				GroupByEdition: true,
+				Options: map[string]interface{}{
+					"keep": 1,
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Decider{
+				Type:    "keepNumberVersions",
+				Edition: "ce",
+				Options: map[string]interface{}{
+					"keep": 0,
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToDecider(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+func TestDeciderKeepExpression(t *testing.T) {
+	tests := []struct {
+		Options    map[string]interface{}
+		ShouldFail bool
+	}{
+		{
+			Options: map[string]interface{}{
+				"expression": "!prev.exists || prev.quarter != quarter || prev.version != version",
+			},
+		},
+		{
+			Options: map[string]interface{}{
+				"expression": "weekday == 0 && hour < 6",
+				"timeZone":   "Australia/Brisbane",
+			},
+		},
+		{
+			Options:    map[string]interface{}{},
+			ShouldFail: true,
+		},
+		{
+			Options: map[string]interface{}{
+				"expression": "size > \"big\"",
+			},
+			ShouldFail: true,
+		},
+		{
+			Options: map[string]interface{}{
+				"expression": "index < 3",
+				"timeZone":   "Nowhere/Special",
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToDecider(&Decider{Type: "keepExpression", Options: test.Options})
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+type deciderKeepKeysConfig struct {
+	Keys []string `mapstructure:"keys"`
+}
+
+func TestRegisterDecider(t *testing.T) {
+	mapping := DeciderMapping{
+		Config: func() interface{} { return &deciderKeepKeysConfig{} },
+		Mapper: func(raw interface{}) (backup.Decider, error) {
+			keys := map[string]bool{}
+			for _, key := range raw.(*deciderKeepKeysConfig).Keys {
+				keys[key] = true
+			}
+			return backup.DeciderFn(func(b *backup.Backup) bool {
+				return keys[b.Key]
+			}), nil
+		},
+	}
+
+	tests := []struct {
+		Name       string
+		Mapping    DeciderMapping
+		ShouldFail bool
+	}{
+		{Name: "testKeepKeys", Mapping: mapping},
+		{Name: "testKeepKeys", Mapping: mapping, ShouldFail: true},
+		{Name: "keepPerVersion", Mapping: mapping, ShouldFail: true},
+		{Name: "", Mapping: mapping, ShouldFail: true},
+		{Name: "testKeepNothing", Mapping: DeciderMapping{}, ShouldFail: true},
+	}
+
+	defer unregisterDecider("testKeepKeys")
+	for i, test := range tests {
+		err := RegisterDecider(test.Name, test.Mapping)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+
+	d, err := ToDecider(&Decider{
+		Type:    "testKeepKeys",
+		Options: map[string]interface{}{"keys": []string{"kept.tar"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error building registered decider: %v", err)
+	}
+	kept := &backup.Backup{Key: "kept.tar"}
+	pruned := &backup.Backup{Key: "pruned.tar"}
+	verdicts := d.Decide(backup.BackupList{kept, pruned})
+	if !verdicts[kept].Keep || verdicts[pruned].Keep {
+		t.Errorf("expected registered decider to keep only kept.tar")
+	}
+	if _, exists := DeciderMapperFactory()["testKeepKeys"]; !exists {
+		t.Errorf("expected registered decider in the mapper factory")
+	}
+}
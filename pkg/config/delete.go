@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+type Delete struct {
+	// Action is delete to remove backups or move to move them into the
+	// quarantine.
+	Action          string `json:"action" yaml:"action" mapstructure:"action"`
+	ContinueOnError bool   `json:"continue_on_error" yaml:"continueOnError" mapstructure:"continueOnError"`
+	Retries         int    `json:"retries" yaml:"retries" mapstructure:"retries"`
+	RetryBackoff    string `json:"retry_backoff" yaml:"retryBackoff" mapstructure:"retryBackoff"`
+	Workers         int    `json:"workers" yaml:"workers" mapstructure:"workers"`
+}
+
+const (
+	DefaultDeleteRetries      = 3
+	DefaultDeleteRetryBackoff = "1s"
+	DefaultDeleteWorkers      = 1
+)
+
+func NewDelete() *Delete {
+	return &Delete{
+		Action:       DeleteActionDelete,
+		Retries:      DefaultDeleteRetries,
+		RetryBackoff: DefaultDeleteRetryBackoff,
+		Workers:      DefaultDeleteWorkers,
+	}
+}
+
+func ToDeleteOptions(conf *Config) (*backup.DeleteOptions, error) {
+	switch conf.Delete.Action {
+	case "", DeleteActionDelete, DeleteActionMove:
+	default:
+		return nil, fmt.Errorf("unknown delete action %s, must be delete or move", conf.Delete.Action)
+	}
+	if conf.Delete.Workers < 1 {
+		return nil, errors.New("delete workers cannot be less than one")
+	}
+	if conf.Delete.Retries < 0 {
+		return nil, errors.New("delete retries cannot be less than zero")
+	}
+
+	opts := &backup.DeleteOptions{
+		ContinueOnError: conf.Delete.ContinueOnError,
+		MaxDeleteCount:  conf.MaxDeleteCount,
+		Retries:         conf.Delete.Retries,
+		Scope:           ToJobScope(conf),
+		Workers:         conf.Delete.Workers,
+	}
+	if conf.Delete.RetryBackoff != "" {
+		backoff, err := time.ParseDuration(conf.Delete.RetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("parsing delete retry backoff: %v", err)
+		}
+		if backoff < time.Duration(0) {
+			return nil, errors.New("delete retry backoff cannot be less than zero")
+		}
+		opts.RetryBackoff = backoff
+	}
+	return opts, nil
+}
+
+// ToTimeout parses the time limit for a single run, zero meaning no limit.
+func ToTimeout(conf *Config) (time.Duration, error) {
+	if conf.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(conf.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("parsing timeout: %v", err)
+	}
+	if timeout < time.Duration(0) {
+		return 0, errors.New("timeout cannot be less than zero")
+	}
+	return timeout, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestDeleteOptions(t *testing.T) {
+	tests := []struct {
+		Delete     *Delete
+		Timeout    string
+		ShouldFail bool
+	}{
+		{
+			Delete: NewDelete(),
+		},
+		{
+			Delete:  &Delete{Workers: 8, Retries: 5, RetryBackoff: "250ms", ContinueOnError: true},
+			Timeout: "1h",
+		},
+		{
+			Delete:     &Delete{Workers: 0},
+			ShouldFail: true,
+		},
+		{
+			Delete:     &Delete{Workers: 1, Retries: -1},
+			ShouldFail: true,
+		},
+		{
+			Delete:     &Delete{Workers: 1, RetryBackoff: "soon"},
+			ShouldFail: true,
+		},
+		{
+			Delete:     NewDelete(),
+			Timeout:    "-1m",
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		conf := New()
+		conf.Delete = test.Delete
+		conf.Timeout = test.Timeout
+		_, err := ToDeleteOptions(conf)
+		if err == nil {
+			_, err = ToTimeout(conf)
+		}
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
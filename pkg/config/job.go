@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+// Job is one bucket to be cleaned up along with how to clean it. Any section
+// left out of a job is taken from the top level of the config.
+type Job struct {
+	Name         string        `json:"name" yaml:"name" mapstructure:"name"`
+	Bucket       *Bucket       `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+	Check        *Check        `json:"check" yaml:"check" mapstructure:"check"`
+	Decider      *Decider      `json:"decider" yaml:"decider" mapstructure:"decider"`
+	Parser       *Parser       `json:"parser" yaml:"parser" mapstructure:"parser"`
+	Unrecognised *Unrecognised `json:"unrecognised" yaml:"unrecognised" mapstructure:"unrecognised"`
+}
+
+// ToJobConfigs returns a config for every job, in order, keyed by job name.
+// A config without any jobs is a single job with an empty name. When name
+// is set only that job is returned.
+func ToJobConfigs(conf *Config, name string) ([]string, map[string]*Config, error) {
+	if len(conf.Jobs) == 0 {
+		if name != "" {
+			return nil, nil, fmt.Errorf("no job named %s, config has no jobs", name)
+		}
+		return []string{""}, map[string]*Config{"": conf}, nil
+	}
+
+	names := []string{}
+	confs := map[string]*Config{}
+	for i, job := range conf.Jobs {
+		if job == nil || job.Name == "" {
+			return nil, nil, fmt.Errorf("job %d must have a name", i)
+		}
+		if _, exists := confs[job.Name]; exists {
+			return nil, nil, fmt.Errorf("duplicate job name %s", job.Name)
+		}
+		logging.Default().Debugf("building config for job %s", job.Name)
+		names = append(names, job.Name)
+		confs[job.Name] = toJobConfig(conf, job)
+	}
+
+	if name == "" {
+		return names, confs, nil
+	}
+	jobConf, exists := confs[name]
+	if !exists {
+		return nil, nil, fmt.Errorf("no job named %s", name)
+	}
+	return []string{name}, map[string]*Config{name: jobConf}, nil
+}
+
+// ToJobConfig returns the config for a single job, which must be named when
+// the config has more than one.
+func ToJobConfig(conf *Config, name string) (*Config, error) {
+	names, confs, err := ToJobConfigs(conf, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) != 1 {
+		return nil, errors.New("config has multiple jobs, a job name is required")
+	}
+	return confs[names[0]], nil
+}
+
+func toJobConfig(conf *Config, job *Job) *Config {
+	jobConf := *conf
+	jobConf.Job = job.Name
+	jobConf.Jobs = nil
+	if job.Bucket != nil {
+		jobConf.Bucket = job.Bucket
+	}
+	if job.Check != nil {
+		jobConf.Check = job.Check
+	}
+	if job.Decider != nil {
+		jobConf.Decider = job.Decider
+	}
+	if job.Parser != nil {
+		jobConf.Parser = job.Parser
+	}
+	if job.Unrecognised != nil {
+		jobConf.Unrecognised = job.Unrecognised
+	}
+	return &jobConf
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestToJobConfigs(t *testing.T) {
+	conf := New()
+	conf.Bucket.URL = "mem://"
+	conf.Decider = &Decider{Type: "keepPerVersion"}
+	conf.Jobs = []*Job{
+		{
+			Name:   "prod",
+			Bucket: &Bucket{URL: "file:///prod"},
+		},
+		{
+			Name:    "staging",
+			Decider: &Decider{Type: "keepNumberVersions"},
+		},
+	}
+
+	names, confs, err := ToJobConfigs(conf, "")
+	if err != nil {
+		t.Fatalf("unexpected error getting job configs: %v", err)
+	}
+	if len(names) != 2 || names[0] != "prod" || names[1] != "staging" {
+		t.Fatalf("unexpected job names %v", names)
+	}
+	if confs["prod"].Bucket.URL != "file:///prod" || confs["prod"].Decider.Type != "keepPerVersion" {
+		t.Errorf("prod job did not override bucket and inherit decider")
+	}
+	if confs["staging"].Bucket.URL != "mem://" || confs["staging"].Decider.Type != "keepNumberVersions" {
+		t.Errorf("staging job did not inherit bucket and override decider")
+	}
+	if confs["prod"].Jobs != nil {
+		t.Error("expected job config to have no jobs")
+	}
+
+	if _, err := ToJobConfig(conf, ""); err == nil {
+		t.Error("expected a single job config without a name to fail with multiple jobs")
+	}
+	jobConf, err := ToJobConfig(conf, "staging")
+	if err != nil {
+		t.Fatalf("unexpected error getting staging job config: %v", err)
+	}
+	if jobConf.Decider.Type != "keepNumberVersions" {
+		t.Errorf("unexpected staging job decider %s", jobConf.Decider.Type)
+	}
+}
+
+func TestToJobConfigsFails(t *testing.T) {
+	tests := []struct {
+		Jobs []*Job
+		Name string
+	}{
+		{
+			Name: "prod",
+		},
+		{
+			Jobs: []*Job{{Name: "prod"}},
+			Name: "staging",
+		},
+		{
+			Jobs: []*Job{{Name: "prod"}, {Name: "prod"}},
+		},
+		{
+			Jobs: []*Job{{}},
+		},
+	}
+
+	for i, test := range tests {
+		conf := New()
+		conf.Jobs = test.Jobs
+		if _, _, err := ToJobConfigs(conf, test.Name); err == nil {
+			t.Errorf("expected test %d to fail with an error", i)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+type Log struct {
+	// Level is the lowest level logged, one of debug, info, warn or error.
+	Level string `json:"level" yaml:"level" mapstructure:"level"`
+	// Format is text for key=value lines or json for one object per line.
+	Format string `json:"format" yaml:"format" mapstructure:"format"`
+}
+
+const (
+	DefaultLogFormat = logging.FormatText
+	DefaultLogLevel  = "info"
+)
+
+func NewLog() *Log {
+	return &Log{
+		Format: DefaultLogFormat,
+		Level:  DefaultLogLevel,
+	}
+}
+
+func ToLogger(conf *Log, w io.Writer) (*logging.Logger, error) {
+	level, err := logging.ParseLevel(conf.Level)
+	if err != nil {
+		return nil, err
+	}
+	if !logging.ValidFormat(conf.Format) {
+		return nil, fmt.Errorf("unknown log format %s, must be text or json", conf.Format)
+	}
+	return logging.New(w, level, conf.Format), nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+func TestToLogger(t *testing.T) {
+	tests := []struct {
+		Log        *Log
+		Debug      bool
+		ShouldFail bool
+	}{
+		{
+			Log: NewLog(),
+		},
+		{
+			Log:   &Log{Level: "debug", Format: logging.FormatJSON},
+			Debug: true,
+		},
+		{
+			Log:        &Log{Level: "verbose", Format: logging.FormatText},
+			ShouldFail: true,
+		},
+		{
+			Log:        &Log{Level: "info", Format: "xml"},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		l, err := ToLogger(test.Log, &bytes.Buffer{})
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if !test.ShouldFail && l.Enabled(logging.LevelDebug) != test.Debug {
+			t.Errorf("test %d expected debug logging %t", i, test.Debug)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+type Metrics struct {
+	Listen   string `json:"listen" yaml:"listen" mapstructure:"listen"`
+	Path     string `json:"path" yaml:"path" mapstructure:"path"`
+	Textfile string `json:"textfile" yaml:"textfile" mapstructure:"textfile"`
+}
+
+const (
+	DefaultMetricsPath = "/metrics"
+)
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Path: DefaultMetricsPath,
+	}
+}
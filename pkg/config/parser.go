@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+const (
+	DefaultParserType = "gitlab"
+)
+
+type Parser struct {
+	Type    string                 `json:"type" yaml:"type" mapstructure:"type"`
+	Options map[string]interface{} `json:"options" yaml:"options" mapstructure:"options"`
+}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+func ToKeyParser(conf *Parser) (backup.KeyParser, error) {
+	factory := ParserMapperFactory()
+	parserType := conf.Type
+	if parserType == "" {
+		parserType = DefaultParserType
+	}
+
+	mapping, found := factory[parserType]
+	if !found {
+		return nil, &ConfigError{Path: "type", Err: fmt.Errorf("no parser mapping for type: %s", parserType)}
+	}
+
+	rawConf := mapping.Config()
+	if err := decodeStrict(conf.Options, rawConf); err != nil {
+		return nil, withPath("options", err)
+	}
+
+	parser, err := mapping.Mapper(rawConf)
+	if err != nil {
+		return nil, withPath("options", err)
+	}
+	return parser, nil
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+type ParserMapper func(conf interface{}) (backup.KeyParser, error)
+
+type ParserMapperConfig func() interface{}
+
+type ParserMapping struct {
+	Config ParserMapperConfig
+	Mapper ParserMapper
+}
+
+func builtinParserMapping(p func() backup.KeyParser) ParserMapping {
+	return ParserMapping{
+		Config: func() interface{} { return &struct{}{} },
+		Mapper: func(_ interface{}) (backup.KeyParser, error) { return p(), nil },
+	}
+}
+
+func ParserMapperFactory() map[string]ParserMapping {
+	return map[string]ParserMapping{
+		"gitlab":                     builtinParserMapping(backup.WithGitLabKeyParser),
+		"gitlabCustomName":           builtinParserMapping(backup.WithCustomNameKeyParser),
+		"gitlabTimestamp":            builtinParserMapping(backup.WithTimestampKeyParser),
+		"gitlabTimestampDate":        builtinParserMapping(backup.WithTimestampDateKeyParser),
+		"gitlabTimestampDateVersion": builtinParserMapping(backup.WithTimestampDateVersionKeyParser),
+		"gitlabTimestampVersion":     builtinParserMapping(backup.WithTimestampVersionKeyParser),
+		"regex": ParserMapping{
+			Config: func() interface{} { return &ParserRegexConfig{} },
+			Mapper: parserRegexMapper,
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+type ParserRegexConfig struct {
+	Pattern    string `mapstructure:"pattern"`
+	TimeLayout string `mapstructure:"timeLayout"`
+}
+
+func parserRegexMapper(raw interface{}) (backup.KeyParser, error) {
+	conf, ok := raw.(*ParserRegexConfig)
+	if !ok {
+		return nil, errors.New("parser regex config is not of type ParserRegexConfig")
+	}
+
+	if conf.Pattern == "" {
+		return nil, withPath("pattern", errors.New("parser regex pattern cannot be null"))
+	}
+	return backup.NewRegexpKeyParser(conf.Pattern, conf.TimeLayout)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		Config     *Parser
+		ShouldFail bool
+	}{
+		{
+			Config:     &Parser{},
+			ShouldFail: false,
+		},
+		{
+			Config: &Parser{
+				Type: "gitlabTimestampVersion",
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Parser{
+				Type: "doesnotexist",
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Parser{
+				Type: "regex",
+				Options: map[string]interface{}{
+					"pattern":    `^(?P<time>\d+)-(?P<version>.+)\.tar$`,
+					"timeLayout": "unix",
+				},
+			},
+			ShouldFail: false,
+		},
+		{
+			Config: &Parser{
+				Type:    "regex",
+				Options: map[string]interface{}{},
+			},
+			ShouldFail: true,
+		},
+		{
+			Config: &Parser{
+				Type: "regex",
+				Options: map[string]interface{}{
+					"pattern": `^(?P<time>\d+`,
+				},
+			},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToKeyParser(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
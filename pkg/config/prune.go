@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+func ToPruneOptions(conf *Config) (*backup.PruneOptions, error) {
+	parser, err := ToKeyParser(conf.Parser)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup key parser: %v", err)
+	}
+
+	unparsablePolicy, err := ToObjectPolicy(conf.Unrecognised.Unparsable)
+	if err != nil {
+		return nil, fmt.Errorf("getting unparsable object policy: %v", err)
+	}
+
+	nonBackupPolicy, err := ToObjectPolicy(conf.Unrecognised.NonBackup)
+	if err != nil {
+		return nil, fmt.Errorf("getting non backup object policy: %v", err)
+	}
+
+	safety, err := ToSafety(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateQuarantine(conf); err != nil {
+		return nil, err
+	}
+
+	return &backup.PruneOptions{
+		Parser:     parser,
+		Unparsable: unparsablePolicy,
+		NonBackup:  nonBackupPolicy,
+		Safety:     safety,
+		Scope:      ToJobScope(conf),
+	}, nil
+}
+
+func ToSafety(conf *Config) (backup.Safety, error) {
+	if conf.MinKeep < 0 {
+		return backup.Safety{}, errors.New("min keep cannot be less than zero")
+	}
+	if conf.MaxDeleteCount < 0 {
+		return backup.Safety{}, errors.New("max delete count cannot be less than zero")
+	}
+	if conf.MaxDeletePercent < 0 || conf.MaxDeletePercent > 100 {
+		return backup.Safety{}, errors.New("max delete percent must be between 0 and 100")
+	}
+
+	return backup.Safety{
+		MinKeep:          conf.MinKeep,
+		MaxDeleteCount:   conf.MaxDeleteCount,
+		MaxDeletePercent: conf.MaxDeletePercent,
+	}, nil
+}
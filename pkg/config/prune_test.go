@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestSafety(t *testing.T) {
+	tests := []struct {
+		Config     *Config
+		ShouldFail bool
+	}{
+		{
+			Config:     &Config{},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Config{MinKeep: 3, MaxDeleteCount: 10, MaxDeletePercent: 25},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Config{MinKeep: -1},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Config{MaxDeleteCount: -1},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Config{MaxDeletePercent: 101},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToSafety(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
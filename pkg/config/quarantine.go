@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+const (
+	DeleteActionDelete = "delete"
+	DeleteActionMove   = "move"
+
+	DefaultQuarantinePrefix = "quarantine/"
+)
+
+// Quarantine is where backups are moved to by the move delete action. An
+// empty URL uses the bucket being cleaned.
+type Quarantine struct {
+	URL        string `json:"url" yaml:"url" mapstructure:"url"`
+	Prefix     string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	PurgeAfter string `json:"purge_after" yaml:"purgeAfter" mapstructure:"purgeAfter"`
+}
+
+func NewQuarantine() *Quarantine {
+	return &Quarantine{
+		Prefix: DefaultQuarantinePrefix,
+	}
+}
+
+// QuarantineEnabled reports whether backups are moved into the quarantine or
+// the quarantine is to be purged.
+func QuarantineEnabled(conf *Config) bool {
+	return conf.Delete.Action == DeleteActionMove || conf.Quarantine.PurgeAfter != ""
+}
+
+// QuarantineInBucket reports whether the quarantine shares the bucket being
+// cleaned, in which case it is left out of listings.
+func QuarantineInBucket(conf *Config) bool {
+	return QuarantineEnabled(conf) &&
+		(conf.Quarantine.URL == "" || conf.Quarantine.URL == conf.Bucket.URL)
+}
+
+// ToQuarantinePrefix is where the job moves backups to under the quarantine
+// prefix. Jobs of a config with more than one share the quarantine so each
+// is given its own namespace named after the job.
+func ToQuarantinePrefix(conf *Config) string {
+	if conf.Job == "" {
+		return conf.Quarantine.Prefix
+	}
+	return conf.Quarantine.Prefix + conf.Job + "/"
+}
+
+// ToJobScope is the bucket scope with the quarantine and audit log excluded
+// when they are in the same bucket.
+func ToJobScope(conf *Config) backup.Scope {
+	scope := ToScope(conf.Bucket)
+	if QuarantineInBucket(conf) && conf.Quarantine.Prefix != "" {
+		scope.Exclude = append(scope.Exclude, conf.Quarantine.Prefix)
+	}
+	if AuditInBucket(conf) && conf.Audit.Prefix != "" {
+		scope.Exclude = append(scope.Exclude, conf.Audit.Prefix)
+	}
+	return scope
+}
+
+// ToPurgeAfter parses how long backups stay quarantined before being purged,
+// zero meaning they are never purged.
+func ToPurgeAfter(conf *Config) (time.Duration, error) {
+	if err := validateQuarantine(conf); err != nil {
+		return 0, err
+	}
+	if conf.Quarantine.PurgeAfter == "" {
+		return 0, nil
+	}
+	purgeAfter, err := time.ParseDuration(conf.Quarantine.PurgeAfter)
+	if err != nil {
+		return 0, fmt.Errorf("parsing quarantine purge after: %v", err)
+	}
+	if purgeAfter < time.Duration(0) {
+		return 0, errors.New("quarantine purge after cannot be less than zero")
+	}
+	return purgeAfter, nil
+}
+
+func validateQuarantine(conf *Config) error {
+	if QuarantineInBucket(conf) && conf.Quarantine.Prefix == "" {
+		return errors.New("quarantine prefix cannot be empty when quarantining in the same bucket")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestQuarantine(t *testing.T) {
+	tests := []struct {
+		Action     string
+		Quarantine *Quarantine
+		Excluded   bool
+		ShouldFail bool
+	}{
+		{
+			Action:     DeleteActionDelete,
+			Quarantine: NewQuarantine(),
+		},
+		{
+			Action:     DeleteActionMove,
+			Quarantine: NewQuarantine(),
+			Excluded:   true,
+		},
+		{
+			Action:     DeleteActionDelete,
+			Quarantine: &Quarantine{Prefix: "quarantine/", PurgeAfter: "720h"},
+			Excluded:   true,
+		},
+		{
+			Action:     DeleteActionMove,
+			Quarantine: &Quarantine{URL: "file:///cold", PurgeAfter: "720h"},
+		},
+		{
+			Action:     DeleteActionMove,
+			Quarantine: &Quarantine{},
+			ShouldFail: true,
+		},
+		{
+			Action:     DeleteActionMove,
+			Quarantine: &Quarantine{Prefix: "quarantine/", PurgeAfter: "a while"},
+			Excluded:   true,
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		conf := New()
+		conf.Bucket.URL = "mem://"
+		conf.Delete.Action = test.Action
+		conf.Quarantine = test.Quarantine
+
+		_, err := ToPurgeAfter(conf)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if excluded := len(ToJobScope(conf).Exclude) > 0; excluded != test.Excluded {
+			t.Errorf("test %d expected quarantine excluded %t, got %t", i, test.Excluded, excluded)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type Schedule struct {
+	Cron   string `json:"cron" yaml:"cron" mapstructure:"cron"`
+	Jitter string `json:"jitter" yaml:"jitter" mapstructure:"jitter"`
+}
+
+func NewSchedule() *Schedule {
+	return &Schedule{}
+}
+
+// ToSchedule parses the standard five field cron expression, or descriptor
+// such as @daily, along with the maximum random delay added to each run.
+func ToSchedule(conf *Schedule) (cron.Schedule, time.Duration, error) {
+	if conf.Cron == "" {
+		return nil, 0, errors.New("schedule cron cannot be null")
+	}
+
+	schedule, err := cron.ParseStandard(conf.Cron)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing schedule cron: %v", err)
+	}
+
+	if conf.Jitter == "" {
+		return schedule, 0, nil
+	}
+	jitter, err := time.ParseDuration(conf.Jitter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing schedule jitter: %v", err)
+	}
+	if jitter < time.Duration(0) {
+		return nil, 0, errors.New("schedule jitter cannot be less than zero")
+	}
+	return schedule, jitter, nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	tests := []struct {
+		Config     *Schedule
+		ShouldFail bool
+	}{
+		{
+			Config:     &Schedule{Cron: "0 3 * * *"},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Schedule{Cron: "@daily", Jitter: "10m"},
+			ShouldFail: false,
+		},
+		{
+			Config:     &Schedule{},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Schedule{Cron: "0 3 * *"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &Schedule{Cron: "@hourly", Jitter: "-1m"},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, _, err := ToSchedule(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
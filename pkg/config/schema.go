@@ -0,0 +1,134 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+	schemaDeciderRef = "#/definitions/decider"
+	schemaParserRef  = "#/definitions/parser"
+)
+
+var (
+	deciderType = reflect.TypeOf(Decider{})
+	parserType  = reflect.TypeOf(Parser{})
+)
+
+// Schema returns a JSON Schema describing the config file. Deciders and
+// parsers are described from their registered mappings, with the options of
+// each type taken from the struct its mapping decodes into.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = SchemaDraft
+	schema["title"] = "gitlab janitor config"
+	schema["definitions"] = map[string]interface{}{
+		"decider": deciderSchema(),
+		"parser":  parserSchema(),
+	}
+	return schema
+}
+
+func deciderSchema() map[string]interface{} {
+	factory := DeciderMapperFactory()
+	types := make([]map[string]interface{}, 0, len(factory))
+	for _, name := range sortedKeys(factory) {
+		types = append(types, mappingSchema(deciderType, name, factory[name].Config(), true))
+	}
+	return map[string]interface{}{"oneOf": types}
+}
+
+func parserSchema() map[string]interface{} {
+	factory := ParserMapperFactory()
+	types := make([]map[string]interface{}, 0, len(factory))
+	for _, name := range sortedKeys(factory) {
+		types = append(types, mappingSchema(parserType, name, factory[name].Config(), name != DefaultParserType))
+	}
+	return map[string]interface{}{"oneOf": types}
+}
+
+// mappingSchema describes a decider or parser of one type, the type being
+// optional for the default.
+func mappingSchema(t reflect.Type, name string, options interface{}, required bool) map[string]interface{} {
+	schema := structSchema(t)
+	properties := schema["properties"].(map[string]interface{})
+	properties["type"] = map[string]interface{}{"const": name}
+	properties["options"] = typeSchema(reflect.TypeOf(options))
+	if required {
+		schema["required"] = []string{"type"}
+	}
+	return schema
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case deciderType:
+		return map[string]interface{}{"$ref": schemaDeciderRef}
+	case parserType:
+		return map[string]interface{}{"$ref": schemaParserRef}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object"}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	// Interfaces accept more than one type, such as a size in bytes or with
+	// units, so are left unconstrained.
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fieldName(field)
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		properties[name] = typeSchema(field.Type)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldName is the key a field is decoded from, the mapstructure name
+// falling back to the yaml name and then the field name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"mapstructure", "yaml"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("unexpected error marshalling schema: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, name := range []string{"bucket", "decider", "jobs", "maxDeleteCount", "unrecognised"} {
+		if _, exists := properties[name]; !exists {
+			t.Errorf("expected config schema property %s", name)
+		}
+	}
+	if _, exists := properties["Job"]; exists {
+		t.Errorf("unexpected config schema property for field without a key")
+	}
+	bucket := properties["bucket"].(map[string]interface{})["properties"].(map[string]interface{})
+	if bucket["recursive"].(map[string]interface{})["type"] != "boolean" {
+		t.Errorf("expected bucket recursive to be a boolean, got %v", bucket["recursive"])
+	}
+
+	definitions := schema["definitions"].(map[string]interface{})
+	tests := []struct {
+		Definition string
+		Factory    interface{}
+	}{
+		{"decider", DeciderMapperFactory()},
+		{"parser", ParserMapperFactory()},
+	}
+	for _, test := range tests {
+		types := map[string]map[string]interface{}{}
+		for _, s := range definitions[test.Definition].(map[string]interface{})["oneOf"].([]map[string]interface{}) {
+			props := s["properties"].(map[string]interface{})
+			types[props["type"].(map[string]interface{})["const"].(string)] = props
+		}
+		for _, name := range sortedKeys(test.Factory) {
+			if _, exists := types[name]; !exists {
+				t.Errorf("expected %s schema for type %s", test.Definition, name)
+			}
+		}
+	}
+
+	deciders := definitions["decider"].(map[string]interface{})["oneOf"].([]map[string]interface{})
+	for _, s := range deciders {
+		props := s["properties"].(map[string]interface{})
+		if props["type"].(map[string]interface{})["const"] != "keepAggregateAgree" {
+			continue
+		}
+		options := props["options"].(map[string]interface{})["properties"].(map[string]interface{})
+		items := options["deciders"].(map[string]interface{})["items"].(map[string]interface{})
+		if items["$ref"] != schemaDeciderRef {
+			t.Errorf("expected aggregate deciders to reference the decider definition, got %v", items)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	byteSizeExpr = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+	byteSizeUnits = map[string]float64{
+		"":    1,
+		"b":   1,
+		"k":   1 << 10,
+		"kb":  1e3,
+		"kib": 1 << 10,
+		"m":   1 << 20,
+		"mb":  1e6,
+		"mib": 1 << 20,
+		"g":   1 << 30,
+		"gb":  1e9,
+		"gib": 1 << 30,
+		"t":   1 << 40,
+		"tb":  1e12,
+		"tib": 1 << 40,
+		"p":   1 << 50,
+		"pb":  1e15,
+		"pib": 1 << 50,
+	}
+)
+
+// parseByteSize converts a human readable size such as 500GiB or 1.5TB into
+// bytes. Plain numbers, as YAML decodes unquoted integers, are taken as bytes.
+func parseByteSize(raw interface{}) (int64, error) {
+	switch v := raw.(type) {
+	case int:
+		return checkByteSize(float64(v))
+	case int64:
+		return checkByteSize(float64(v))
+	case uint64:
+		return checkByteSize(float64(v))
+	case float64:
+		return checkByteSize(v)
+	case string:
+		matches := byteSizeExpr.FindStringSubmatch(strings.TrimSpace(v))
+		if matches == nil {
+			return 0, fmt.Errorf("invalid byte size %q", v)
+		}
+		multiplier, exists := byteSizeUnits[strings.ToLower(matches[2])]
+		if !exists {
+			return 0, fmt.Errorf("unknown byte size unit %s", matches[2])
+		}
+		value, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing byte size %q: %v", v, err)
+		}
+		return checkByteSize(value * multiplier)
+	case nil:
+		return 0, fmt.Errorf("byte size cannot be empty")
+	}
+	return 0, fmt.Errorf("unsupported byte size type %T", raw)
+}
+
+func checkByteSize(size float64) (int64, error) {
+	if size < 0 {
+		return 0, fmt.Errorf("byte size cannot be less than zero")
+	}
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %.0f is too large", size)
+	}
+	return int64(size), nil
+}
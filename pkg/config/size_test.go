@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		Raw        interface{}
+		Expected   int64
+		ShouldFail bool
+	}{
+		{Raw: "500GiB", Expected: 500 << 30},
+		{Raw: "1.5TB", Expected: 1500000000000},
+		{Raw: "10 mb", Expected: 10000000},
+		{Raw: "2k", Expected: 2048},
+		{Raw: "100", Expected: 100},
+		{Raw: 1048576, Expected: 1048576},
+		{Raw: "", ShouldFail: true},
+		{Raw: "12XB", ShouldFail: true},
+		{Raw: "-1GiB", ShouldFail: true},
+		{Raw: -1, ShouldFail: true},
+		{Raw: nil, ShouldFail: true},
+		{Raw: "10000PiB", ShouldFail: true},
+	}
+
+	for i, test := range tests {
+		size, err := parseByteSize(test.Raw)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if size != test.Expected {
+			t.Errorf("test %d expected %d bytes, got %d", i, test.Expected, size)
+		}
+	}
+}
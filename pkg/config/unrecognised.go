@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+type ObjectPolicy struct {
+	Action string `json:"action" yaml:"action" mapstructure:"action"`
+	After  string `json:"after" yaml:"after" mapstructure:"after"`
+}
+
+type Unrecognised struct {
+	Unparsable *ObjectPolicy `json:"unparsable" yaml:"unparsable" mapstructure:"unparsable"`
+	NonBackup  *ObjectPolicy `json:"non_backup" yaml:"nonBackup" mapstructure:"nonBackup"`
+}
+
+func NewUnrecognised() *Unrecognised {
+	return &Unrecognised{
+		Unparsable: &ObjectPolicy{Action: string(backup.ObjectActionWarn)},
+		NonBackup:  &ObjectPolicy{Action: string(backup.ObjectActionIgnore)},
+	}
+}
+
+func ToObjectPolicy(conf *ObjectPolicy) (backup.ObjectPolicy, error) {
+	policy := backup.ObjectPolicy{}
+	if conf == nil {
+		return policy, nil
+	}
+
+	policy.Action = backup.ObjectAction(conf.Action)
+	if policy.Action == "" {
+		return policy, nil
+	}
+	if !policy.Action.Valid() {
+		return policy, fmt.Errorf("unknown object policy action: %s", conf.Action)
+	}
+
+	if policy.Action != backup.ObjectActionPruneAfter {
+		if conf.After != "" {
+			return policy, fmt.Errorf("object policy after is only valid for action %s", backup.ObjectActionPruneAfter)
+		}
+		return policy, nil
+	}
+
+	if conf.After == "" {
+		return policy, fmt.Errorf("object policy action %s requires an after duration", backup.ObjectActionPruneAfter)
+	}
+	after, err := time.ParseDuration(conf.After)
+	if err != nil {
+		return policy, fmt.Errorf("parsing object policy after duration: %v", err)
+	}
+	if after < time.Duration(0) {
+		return policy, errors.New("object policy after duration cannot be less than zero")
+	}
+	policy.After = after
+	return policy, nil
+}
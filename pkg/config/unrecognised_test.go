@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestObjectPolicy(t *testing.T) {
+	tests := []struct {
+		Config     *ObjectPolicy
+		ShouldFail bool
+	}{
+		{
+			Config:     nil,
+			ShouldFail: false,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "warn"},
+			ShouldFail: false,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "prune-after", After: "72h"},
+			ShouldFail: false,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "prune-after"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "prune-after", After: "-1h"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "fail", After: "1h"},
+			ShouldFail: true,
+		},
+		{
+			Config:     &ObjectPolicy{Action: "delete"},
+			ShouldFail: true,
+		},
+	}
+
+	for i, test := range tests {
+		_, err := ToObjectPolicy(test.Config)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ConfigError is a problem with the config value at Path, a dotted path such
+// as decider.options.deciders[1].options.count.
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// withPath places err under path, prefixing the path of an existing
+// *ConfigError.
+func withPath(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cerr, ok := err.(*ConfigError); ok {
+		return &ConfigError{Path: joinPath(path, cerr.Path), Err: cerr.Err}
+	}
+	return &ConfigError{Path: path, Err: err}
+}
+
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	case strings.HasPrefix(child, "["):
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+// decodeStrict decodes input into output failing on any key in input that
+// output does not have.
+func decodeStrict(input interface{}, output interface{}) error {
+	meta := &mapstructure.Metadata{}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Metadata: meta,
+		Result:   output,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return decodeError(err)
+	}
+	return unusedError(meta)
+}
+
+func unusedError(meta *mapstructure.Metadata) error {
+	if len(meta.Unused) == 0 {
+		return nil
+	}
+	sort.Strings(meta.Unused)
+	return &ConfigError{Path: meta.Unused[0], Err: errors.New("unknown key")}
+}
+
+// decodeError turns the first mapstructure error into a *ConfigError
+// located at the first field quoted in the error.
+func decodeError(err error) error {
+	merr, ok := err.(*mapstructure.Error)
+	if !ok || len(merr.Errors) == 0 {
+		return err
+	}
+	msg := merr.Errors[0]
+	start := strings.Index(msg, "'")
+	if start < 0 {
+		return errors.New(msg)
+	}
+	end := strings.Index(msg[start+1:], "'")
+	if end < 0 {
+		return errors.New(msg)
+	}
+	path := msg[start+1 : start+1+end]
+	if start == 0 {
+		// The error starts with the quoted field so is trimmed to avoid
+		// repeating it.
+		msg = strings.TrimSpace(msg[end+2:])
+	}
+	return &ConfigError{Path: path, Err: errors.New(msg)}
+}
+
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("parsing url: %v", err)
+	}
+	if u.Scheme == "" {
+		return fmt.Errorf("url %s has no scheme", raw)
+	}
+	return nil
+}
+
+func validateBucket(conf *Bucket) error {
+	if conf.URL == "" {
+		return &ConfigError{Path: "url", Err: errors.New("bucket url cannot be null")}
+	}
+	return withPath("url", validateURL(conf.URL))
+}
+
+// Validate checks every section of the config, and of each job, can be
+// built without opening any bucket. The first problem found is returned as
+// a *ConfigError locating it in the config.
+func Validate(conf *Config) error {
+	names, confs, err := ToJobConfigs(conf, "")
+	if err != nil {
+		return withPath("jobs", err)
+	}
+
+	checks := []struct {
+		path  string
+		check func() error
+	}{
+		{"", func() error {
+			_, err := ToSafety(conf)
+			return err
+		}},
+		{"audit", func() error { return validateAudit(conf.Audit) }},
+		{"delete", func() error {
+			_, err := ToDeleteOptions(conf)
+			return err
+		}},
+		{"log", func() error {
+			_, err := ToLogger(conf.Log, ioutil.Discard)
+			return err
+		}},
+		{"quarantine", func() error {
+			if conf.Quarantine.URL != "" {
+				if err := validateURL(conf.Quarantine.URL); err != nil {
+					return withPath("url", err)
+				}
+			}
+			_, err := ToPurgeAfter(conf)
+			return err
+		}},
+		{"schedule", func() error {
+			if conf.Schedule.Cron == "" {
+				return nil
+			}
+			_, _, err := ToSchedule(conf.Schedule)
+			return err
+		}},
+		{"timeout", func() error {
+			_, err := ToTimeout(conf)
+			return err
+		}},
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			return withPath(c.path, err)
+		}
+	}
+
+	for i, name := range names {
+		var job *Job
+		if name != "" {
+			job = conf.Jobs[i]
+		}
+		if err := validateJob(confs[name], job, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateJob checks the sections a job may override, locating problems
+// in the job when it overrides the section.
+func validateJob(conf *Config, job *Job, index int) error {
+	checks := []struct {
+		path       string
+		overridden bool
+		check      func() error
+	}{
+		{"bucket", job != nil && job.Bucket != nil, func() error { return validateBucket(conf.Bucket) }},
+		{"audit", false, func() error { return validateAuditInBucket(conf) }},
+		{"check", job != nil && job.Check != nil, func() error {
+			if *conf.Check == (Check{}) {
+				return nil
+			}
+			_, err := ToCheckOptions(conf.Check)
+			return err
+		}},
+		{"decider", job != nil && job.Decider != nil, func() error {
+			_, err := ToDecider(conf.Decider)
+			return err
+		}},
+		{"parser", job != nil && job.Parser != nil, func() error {
+			_, err := ToKeyParser(conf.Parser)
+			return err
+		}},
+		{"unrecognised.unparsable", job != nil && job.Unrecognised != nil, func() error {
+			_, err := ToObjectPolicy(conf.Unrecognised.Unparsable)
+			return err
+		}},
+		{"unrecognised.nonBackup", job != nil && job.Unrecognised != nil, func() error {
+			_, err := ToObjectPolicy(conf.Unrecognised.NonBackup)
+			return err
+		}},
+	}
+	for _, c := range checks {
+		if err := c.check(); err != nil {
+			if c.overridden {
+				return withPath(fmt.Sprintf("jobs[%d].%s", index, c.path), err)
+			}
+			return withPath(c.path, err)
+		}
+	}
+	return nil
+}
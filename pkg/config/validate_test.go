@@ -0,0 +1,187 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		Config string
+		Path   string
+	}{
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+`,
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+  prefx: backups/
+`,
+			Path: "bucket.prefx",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+minKeep: some
+`,
+			Path: "minKeep",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepAfterDuration
+  options:
+    durration: 24h
+`,
+			Path: "decider.options.durration",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepAggregateAgree
+  options:
+    deciders:
+      - type: keepPerVersion
+        options:
+          count: 1
+      - type: keepPerVersion
+        options:
+          count: -1
+`,
+			Path: "decider.options.deciders[1].options.count",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepPerVersion
+`,
+			Path: "decider.options.count",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepFirstMatch
+  options:
+    deciders:
+      - type: keepPerVersion
+        options:
+          kep: 1
+`,
+			Path: "decider.options.deciders[0].options.kep",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+parser:
+  type: regex
+  options:
+    patern: ^(?P<time>\d+)\.tar$
+`,
+			Path: "parser.options.patern",
+		},
+		{
+			Config: `
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+jobs:
+  - name: prod
+    bucket:
+      url: mem://
+  - name: staging
+    bucket:
+      url: mem://
+    decider:
+      type: keepEverything
+`,
+			Path: "jobs[1].decider.type",
+		},
+		{
+			Config: `
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+`,
+			Path: "bucket.url",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+delete:
+  action: shred
+`,
+			Path: "delete",
+		},
+		{
+			Config: `
+bucket:
+  url: mem://
+decider:
+  type: keepPerVersion
+  options:
+    count: 1
+audit:
+  url: mem://
+`,
+			Path: "audit",
+		},
+	}
+
+	for i, test := range tests {
+		builder := NewBuilder()
+		builder.Viper.SetConfigType("yaml")
+		if err := builder.Viper.ReadConfig(strings.NewReader(test.Config)); err != nil {
+			t.Fatalf("unexpected error reading config for test %d: %v", i, err)
+		}
+
+		conf, err := builder.Build()
+		if err == nil {
+			err = Validate(conf)
+		}
+		if err == nil && test.Path != "" {
+			t.Fatalf("expected test %d to fail with an error at %s", i, test.Path)
+		} else if err != nil && test.Path == "" {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if err == nil {
+			continue
+		}
+		cerr, ok := err.(*ConfigError)
+		if !ok {
+			t.Fatalf("expected test %d to fail with a config error, got %v", i, err)
+		}
+		if cerr.Path != test.Path {
+			t.Errorf("test %d expected error at %s, got %v", i, test.Path, err)
+		}
+	}
+}
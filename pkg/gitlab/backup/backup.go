@@ -1,13 +1,23 @@
 package backup
 
 import (
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-version"
 )
 
 type Backup struct {
+	// Edition is the GitLab edition, such as ce or ee, that created the
+	// backup. It is kept out of Version so it does not affect comparisons.
+	Edition string
 	Key     string
+	MD5     []byte
+	ModTime time.Time
+	Size    int64
 	Time    time.Time
 	Version *version.Version
 }
@@ -22,13 +32,87 @@ func (l BackupList) Less(i, j int) bool {
 	if l[i].Time.After(l[j].Time) {
 		return true
 	} else if l[i].Time.Equal(l[j].Time) {
-		return l[i].Version.GreaterThan(l[j].Version)
+		return versionGreaterThan(l[i].Version, l[j].Version)
 	}
 	return false
 }
 
+// Sorted returns a copy of the list ordered newest first.
+func (l BackupList) Sorted() BackupList {
+	sorted := make(BackupList, len(l))
+	copy(sorted, l)
+	sort.Sort(sorted)
+	return sorted
+}
+
 func (l BackupList) Swap(i, j int) {
 	tmp := l[i]
 	l[i] = l[j]
 	l[j] = tmp
 }
+
+func versionGreaterThan(v1, v2 *version.Version) bool {
+	if v1 == nil || v2 == nil {
+		return v1 != nil
+	}
+	return v1.GreaterThan(v2)
+}
+
+func (b *Backup) versionKey() string {
+	if b.Version == nil {
+		return ""
+	}
+	return withEdition(b.Version.String(), b.Edition)
+}
+
+func (b *Backup) versionName() string {
+	if b.Version == nil {
+		return "unknown version"
+	}
+	return withEdition(b.Version.Original(), b.Edition)
+}
+
+func (b *Backup) editionName() string {
+	if b.Edition == "" {
+		return "unknown edition"
+	}
+	return b.Edition
+}
+
+func withEdition(ver, edition string) string {
+	if edition == "" {
+		return ver
+	}
+	return ver + "-" + edition
+}
+
+// coreVersion is the backup version without any pre-release or metadata so
+// release candidates still satisfy constraints on their release series.
+func (b *Backup) coreVersion() *version.Version {
+	if b.Version == nil {
+		return nil
+	}
+	return version.Must(version.NewVersion(b.seriesName(len(b.Version.Segments64()))))
+}
+
+// seriesName is the first segments of the backup version joined with dots,
+// such as 12.0 for major.minor.
+func (b *Backup) seriesName(segments int) string {
+	if b.Version == nil {
+		return ""
+	}
+	parts := []string{}
+	for i, segment := range b.Version.Segments64() {
+		if i == segments {
+			break
+		}
+		parts = append(parts, strconv.FormatInt(segment, 10))
+	}
+	return strings.Join(parts, ".")
+}
+
+// MD5Hex is the hex encoded MD5 hash of the backup contents or an empty
+// string when the bucket provider did not supply one.
+func (b *Backup) MD5Hex() string {
+	return hex.EncodeToString(b.MD5)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-version"
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/memblob"
 )
@@ -93,7 +94,7 @@ func TestWithKeepNumberOfVersions(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error creating prune list: %v", err)
 		}
-		if !comparePruneLists(res, test.PruneFiles) {
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
 			t.Errorf("test %d prune list does not match expected post list", i)
 		}
 	}
@@ -145,7 +146,7 @@ func TestWithKeepTime(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error creating prune list: %v", err)
 		}
-		if !comparePruneLists(res, test.PruneFiles) {
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
 			t.Errorf("test %d prune list does not match expected post list", i)
 		}
 	}
@@ -194,7 +195,7 @@ func TestWithKeepPerVersion(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error creating prune list: %v", err)
 		}
-		if !comparePruneLists(res, test.PruneFiles) {
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
 			t.Errorf("test %d prune list does not match expected post list", i)
 		}
 	}
@@ -277,7 +278,7 @@ func TestWithFirstKeepMatch(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error creating prune list: %v", err)
 		}
-		if !comparePruneLists(res, test.PruneFiles) {
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
 			t.Errorf("test %d prune list does not match expected post list", i)
 		}
 	}
@@ -348,12 +349,872 @@ func TestWithAggregateAgree(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error creating prune list: %v", err)
 		}
-		if !comparePruneLists(res, test.PruneFiles) {
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
 			t.Errorf("test %d prune list does not match expected post list", i)
 		}
 	}
 }
 
+func TestWithKeepCalendarBuckets(t *testing.T) {
+	tests := []struct {
+		Decision   Decider
+		PreFiles   []string
+		PruneFiles []string
+	}{
+		{
+			Decision: WithKeepCalendarBuckets(2, 0, 2, 2, time.UTC),
+			PreFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.0-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1561776781_2019_06_29_11.7.0-ee_gitlab_backup.tar",
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1561776781_2019_06_29_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepCalendarBuckets(0, 3, 0, 0, time.UTC),
+			PreFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.0-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1561776781_2019_06_29_11.7.0-ee_gitlab_backup.tar",
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.0-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1561776781_2019_06_29_11.7.0-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepCalendarBuckets(0, 2, 0, 0, time.FixedZone("UTC-4", -4*60*60)),
+			PreFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			}},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, test.PreFiles); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list does not match expected post list", i)
+		}
+	}
+}
+
+func TestWithKeepWithinSize(t *testing.T) {
+	tests := []struct {
+		Decision   Decider
+		PreFiles   []string
+		PruneFiles []string
+	}{
+		{
+			Decision: WithKeepWithinSize(35),
+			PreFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564797618_2019_08_03_12.0.3-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564797618_2019_08_03_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepWithinSize(30),
+			PreFiles: []string{
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{}},
+		{
+			Decision: WithKeepWithinSize(5),
+			PreFiles: []string{
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			}},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, test.PreFiles); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list does not match expected post list", i)
+		}
+	}
+}
+
+func mustConstraint(raw string) version.Constraints {
+	c, err := version.NewConstraint(raw)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func TestWithKeepVersionSeries(t *testing.T) {
+	files := []string{
+		"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+		"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+		"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+		"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+		"1543370414_2018_11_28_11.4.1-ee_gitlab_backup.tar",
+		"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+		"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.1.0-ee_gitlab_backup.tar",
+		"1565056900_gitlab_backup.tar",
+	}
+
+	tests := []struct {
+		Decision   Decider
+		PruneFiles []string
+	}{
+		{
+			Decision: WithKeepVersionConstraint(mustConstraint(">= 11.4, < 12")),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.1.0-ee_gitlab_backup.tar",
+				"1565056900_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepPerSeries(1, SeriesMajor),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.1-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepPerSeries(2, SeriesMinor),
+			PruneFiles: []string{
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+			}},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, files); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list %v does not match expected post list", i, res.Prune)
+		}
+	}
+}
+
+func mustExpression(src string) *Expression {
+	e, err := ParseExpression(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func TestWithKeepExpression(t *testing.T) {
+	files := []string{
+		"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+		"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+		"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+		"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+		"1543370414_2018_11_28_11.4.1-ee_gitlab_backup.tar",
+		"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+		"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.1.0-ee_gitlab_backup.tar",
+		"1565056900_gitlab_backup.tar",
+	}
+
+	tests := []struct {
+		Decision   Decider
+		PruneFiles []string
+	}{
+		{
+			Decision: WithKeepExpression(mustExpression(
+				"!prev.exists || prev.year != year || prev.quarter != quarter || prev.version != version"), nil),
+			PruneFiles: []string{
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepExpression(mustExpression("major >= 12 && index < 2"), nil),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.1-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056900_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithKeepExpression(mustExpression("next.exists && next.time - time < 1d"), nil),
+			PruneFiles: []string{
+				"1540174453_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1543284005_2018_11_27_11.4.0-ee_gitlab_backup.tar",
+				"1543370414_2018_11_28_11.4.1-ee_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056900_gitlab_backup.tar",
+			}},
+		{
+			Decision:   WithKeepExpression(mustExpression("size / (index - index) > 0"), nil),
+			PruneFiles: []string{},
+		},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, files); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list %v does not match expected post list", i, res.Prune)
+		}
+	}
+}
+
+func TestEditionDeciders(t *testing.T) {
+	files := []string{
+		"1540174211_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+		"1540174453_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+		"1543197673_2018_11_26_11.4.0-ce_gitlab_backup.tar",
+		"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+		"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+	}
+
+	tests := []struct {
+		Decision   Decider
+		PruneFiles []string
+	}{
+		{
+			Decision: WithEdition("EE", WithKeepPerVersion(1)),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ce_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithFirstKeepMatch(true,
+				WithEdition("ee", WithKeepNumberOfVersions(1)),
+				WithEdition("ce", WithKeepPerVersion(1)),
+			),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithGroupByEdition(WithKeepNumberOfVersions(1)),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6-ce_gitlab_backup.tar",
+				"1561696174_2019_06_28_11.7.0-ee_gitlab_backup.tar",
+			}},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, files); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list %v does not match expected post list", i, res.Prune)
+		}
+	}
+}
+
+// TestEditionDecidersUnsuffixed covers backups with no edition suffix, which
+// are of unknown edition.
+func TestEditionDecidersUnsuffixed(t *testing.T) {
+	files := []string{
+		"1540174211_2018_10_22_11.3.6_gitlab_backup.tar",
+		"1540174453_2018_10_22_11.3.6_gitlab_backup.tar",
+		"1543197673_2018_11_26_11.4.0_gitlab_backup.tar",
+		"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+	}
+
+	tests := []struct {
+		Decision   Decider
+		PruneFiles []string
+	}{
+		{
+			Decision: WithEdition("ce", WithKeepPerVersion(1)),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithEdition("ee", WithKeepPerVersion(1)),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6_gitlab_backup.tar",
+				"1540174453_2018_10_22_11.3.6_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			}},
+		{
+			Decision: WithGroupByEdition(WithKeepPerVersion(1)),
+			PruneFiles: []string{
+				"1540174211_2018_10_22_11.3.6_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			}},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, files); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneList(bucket, test.Decision)
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list %v does not match expected post list", i, res.Prune)
+		}
+	}
+}
+
+func TestUnrecognisedObjectPolicies(t *testing.T) {
+	tests := []struct {
+		Options    *PruneOptions
+		ShouldFail bool
+		PruneFiles []string
+	}{
+		{
+			Options:    &PruneOptions{},
+			PruneFiles: []string{},
+		},
+		{
+			Options: &PruneOptions{
+				Unparsable: ObjectPolicy{Action: ObjectActionFail},
+			},
+			ShouldFail: true,
+		},
+		{
+			Options: &PruneOptions{
+				NonBackup: ObjectPolicy{Action: ObjectActionFail},
+			},
+			ShouldFail: true,
+		},
+		{
+			Options: &PruneOptions{
+				Unparsable: ObjectPolicy{Action: ObjectActionPruneAfter},
+				NonBackup:  ObjectPolicy{Action: ObjectActionPruneAfter, After: time.Hour},
+			},
+			PruneFiles: []string{
+				"1565056820_2019_08_06_not!a!version_gitlab_backup.tar",
+			},
+		},
+		{
+			Options: &PruneOptions{
+				Unparsable: ObjectPolicy{Action: ObjectActionIgnore},
+				NonBackup:  ObjectPolicy{Action: ObjectActionPruneAfter},
+			},
+			PruneFiles: []string{
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar.part",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, []string{
+			"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			"1565056820_2019_08_06_not!a!version_gitlab_backup.tar",
+			"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar.part",
+		}); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneListWithOptions(context.Background(), bucket,
+			WithAggregateAgree(), test.Options)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		} else if err != nil {
+			continue
+		}
+
+		if len(res.Backups) != 1 || len(res.Unparsable) != 1 || len(res.NonBackup) != 1 {
+			t.Errorf("test %d expected one backup, unparsable and non backup object, got %d, %d, %d",
+				i, len(res.Backups), len(res.Unparsable), len(res.NonBackup))
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list does not match expected post list", i)
+		}
+	}
+}
+
+func TestExplainReasons(t *testing.T) {
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	if err := createDummyFiles(bucket, []string{
+		"1543370414_2018_11_28_11.4.0-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	res, err := CreatePruneList(bucket, WithFirstKeepMatch(false,
+		WithKeepNumberOfVersions(1),
+		WithKeepPerVersion(1)))
+	if err != nil {
+		t.Fatalf("unexpected error creating prune list: %v", err)
+	}
+
+	expected := map[string]string{
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar": "kept: keepFirstMatch no decider matched false\n" +
+			"  kept: keepNumberVersions version 12.0.3-ee is 1/1 newest versions\n" +
+			"  kept: keepPerVersion count 1/1 for 12.0.3-ee",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar": "pruned: keepFirstMatch decider 1 matched false\n" +
+			"  kept: keepNumberVersions version 12.0.3-ee is within newest 1 versions\n" +
+			"  pruned: keepPerVersion already kept 1/1 for 12.0.3-ee",
+		"1543370414_2018_11_28_11.4.0-ee_gitlab_backup.tar": "pruned: keepFirstMatch decider 0 matched false\n" +
+			"  pruned: keepNumberVersions version 11.4.0-ee is not within newest 1 versions",
+	}
+	for _, b := range res.Backups {
+		verdict := res.Verdicts[b]
+		if verdict.Reason == nil {
+			t.Fatalf("backup %s has no reason", b.Key)
+		}
+		if verdict.Keep != verdict.Reason.Keep {
+			t.Errorf("backup %s verdict does not match reason", b.Key)
+		}
+		if reason := verdict.Reason.String(); reason != expected[b.Key] {
+			t.Errorf("backup %s reason:\n%s\ndoes not match expected:\n%s", b.Key, reason, expected[b.Key])
+		}
+	}
+}
+
+func TestDecidersAreReusable(t *testing.T) {
+	parser := WithGitLabKeyParser()
+	backups := BackupList{}
+	for _, key := range []string{
+		"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+		"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+		"1561776781_2019_06_29_11.7.0-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+	} {
+		b, err := parser.Parse(key)
+		if err != nil {
+			t.Fatalf("unexpected error parsing key %s: %v", key, err)
+		}
+		backups = append(backups, b)
+	}
+
+	tests := []struct {
+		Decision Decider
+		Kept     []string
+	}{
+		{
+			Decision: WithKeepPerVersion(1),
+			Kept: []string{
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+				"1540174211_2018_10_22_11.3.6-ee_gitlab_backup.tar",
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Decision: WithFirstKeepMatch(false,
+				WithKeepNumberOfVersions(2),
+				WithKeepPerVersion(1)),
+			Kept: []string{
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Decision: WithKeepCalendarBuckets(0, 0, 1, 2, time.UTC),
+			Kept: []string{
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+				"1543197673_2018_11_26_11.4.0-ee_gitlab_backup.tar",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		for run := 0; run < 2; run++ {
+			verdicts := test.Decision.Decide(backups)
+			if len(verdicts) != len(backups) {
+				t.Fatalf("test %d run %d expected %d verdicts, got %d", i, run, len(backups), len(verdicts))
+			}
+			kept := []string{}
+			for _, b := range backups {
+				if verdicts[b].Keep {
+					kept = append(kept, b.Key)
+				}
+			}
+			if !comparePruneLists(kept, test.Kept) {
+				t.Errorf("test %d run %d kept list %v does not match expected", i, run, kept)
+			}
+		}
+	}
+}
+
+func TestDeciderFnAdapter(t *testing.T) {
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	if err := createDummyFiles(bucket, []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	res, err := CreatePruneList(bucket, DeciderFn(func(b *Backup) bool {
+		return b.Key == "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar"
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error creating prune list: %v", err)
+	}
+	if !comparePruneLists(res.Prune, []string{"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar"}) {
+		t.Errorf("decider fn prune list does not match expected post list")
+	}
+}
+
+func TestSafety(t *testing.T) {
+	tests := []struct {
+		Safety     Safety
+		LimitError bool
+		PruneFiles []string
+	}{
+		{
+			Safety: Safety{MinKeep: 2},
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Safety:     Safety{MaxDeleteCount: 3},
+			LimitError: true,
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Safety: Safety{MinKeep: 1, MaxDeleteCount: 3},
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Safety:     Safety{MinKeep: 1, MaxDeletePercent: 50},
+			LimitError: true,
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+				"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Safety: Safety{MinKeep: 2, MaxDeletePercent: 50},
+			PruneFiles: []string{
+				"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+				"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, []string{
+			"1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+			"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+			"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+		}); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneListWithOptions(context.Background(), bucket,
+			WithKeepAfterTime(time.Date(2020, 01, 01, 0, 0, 0, 0, time.UTC)),
+			&PruneOptions{Safety: test.Safety})
+		if _, limited := err.(*DeleteLimitError); limited != test.LimitError {
+			t.Fatalf("test %d expected delete limit error %t, got %v", i, test.LimitError, err)
+		} else if err != nil && !limited {
+			t.Fatalf("unexpected error creating prune list for test %d: %v", i, err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list does not match expected post list", i)
+		}
+	}
+}
+
+func TestDeleteMaxDeleteCount(t *testing.T) {
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	if err := createDummyFiles(bucket, files); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	err = DeletePruneListWithOptions(context.Background(), bucket, files, &DeleteOptions{MaxDeleteCount: 1})
+	if _, limited := err.(*DeleteLimitError); !limited {
+		t.Fatalf("expected delete limit error, got %v", err)
+	}
+	for _, file := range files {
+		if exists, err := bucket.Exists(context.Background(), file); err != nil || !exists {
+			t.Errorf("expected backup %s to remain after refused deletion", file)
+		}
+	}
+}
+
+func TestListBackupsAttributes(t *testing.T) {
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	key := "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar"
+	if err := createDummyFiles(bucket, []string{key}); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	listing, err := ListBackups(context.Background(), bucket, nil)
+	if err != nil {
+		t.Fatalf("unexpected error listing backups: %v", err)
+	}
+	if len(listing.Backups) != 1 {
+		t.Fatalf("expected 1 backup, got %d", len(listing.Backups))
+	}
+
+	b := listing.Backups[0]
+	if b.Size != int64(len(DummyData)) {
+		t.Errorf("expected backup size %d, got %d", len(DummyData), b.Size)
+	}
+	if b.MD5Hex() != "31bfb9730ae51af73953ba720c8660d7" {
+		t.Errorf("expected backup md5 31bfb9730ae51af73953ba720c8660d7, got %s", b.MD5Hex())
+	}
+	if b.ModTime.IsZero() {
+		t.Error("expected backup modification time to be populated")
+	}
+}
+
+func TestScope(t *testing.T) {
+	bucket, err := blob.OpenBucket(context.Background(), "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	files := []string{
+		"gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"gitlab-prod/1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+		"gitlab-prod/old/1561863334_2019_06_30_11.7.0-ee_gitlab_backup.tar",
+		"gitlab-staging/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1564884018_2019_08_04_12.0.3-ee_gitlab_backup.tar",
+	}
+	if err := createDummyFiles(bucket, files); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	parser, err := NewRegexpKeyParser(`^(?P<time>\d+)_\d{4}_\d{2}_\d{2}_(?P<version>[^_]+)_gitlab_backup\.tar$`, "")
+	if err != nil {
+		t.Fatalf("unexpected error creating key parser: %v", err)
+	}
+
+	tests := []struct {
+		Scope     Scope
+		PruneList []string
+		NonBackup int
+	}{
+		{
+			Scope: Scope{Prefix: "gitlab-prod/"},
+			PruneList: []string{
+				"gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			},
+			NonBackup: 1,
+		},
+		{
+			Scope: Scope{Prefix: "gitlab-prod/", Delimiter: "/"},
+			PruneList: []string{
+				"gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+			},
+		},
+		{
+			Scope:     Scope{Prefix: "gitlab-staging/"},
+			PruneList: []string{},
+		},
+	}
+
+	for i, test := range tests {
+		res, err := CreatePruneListWithOptions(context.Background(), bucket, WithKeepPerVersion(1), &PruneOptions{
+			Parser: parser,
+			Scope:  test.Scope,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list for test %d: %v", i, err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneList) {
+			t.Errorf("test %d prune list %v does not match expected", i, res.Prune)
+		}
+		if len(res.NonBackup) != test.NonBackup {
+			t.Errorf("test %d expected %d non backup objects, got %d", i, test.NonBackup, len(res.NonBackup))
+		}
+	}
+
+	err = DeletePruneListWithOptions(context.Background(), bucket, files, &DeleteOptions{
+		Scope: Scope{Prefix: "gitlab-prod/"},
+	})
+	if err == nil {
+		t.Fatal("expected deleting keys outside of the scope prefix to fail")
+	}
+	for _, file := range files {
+		if exists, err := bucket.Exists(context.Background(), file); err != nil || !exists {
+			t.Errorf("expected %s to remain after refused deletion", file)
+		}
+	}
+}
+
 func TestRemovesPruneList(t *testing.T) {
 	tests := []struct {
 		PreFiles  []string
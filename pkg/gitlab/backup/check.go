@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	CheckFreshness = "freshness"
+	CheckGap       = "gap"
+	CheckSize      = "size"
+)
+
+// CheckOptions configures the backup health checks. A zero value disables
+// the corresponding check.
+type CheckOptions struct {
+	// MaxAge is the oldest the newest backup may be.
+	MaxAge time.Duration
+	// Interval is the largest expected time between consecutive backups.
+	Interval time.Duration
+	// GapWindow limits gap detection to backups newer than the window, since
+	// retention is expected to leave gaps between older backups.
+	GapWindow time.Duration
+	// MinSizeRatio is the smallest the newest backup may be as a fraction of
+	// the previous backup.
+	MinSizeRatio float64
+}
+
+type CheckProblem struct {
+	Check   string
+	Message string
+}
+
+func (p CheckProblem) String() string {
+	return fmt.Sprintf("%s: %s", p.Check, p.Message)
+}
+
+// Check verifies backups, sorted newest first, are still being produced.
+func Check(backups BackupList, opts CheckOptions, now time.Time) []CheckProblem {
+	problems := []CheckProblem{}
+	if len(backups) == 0 {
+		if opts.MaxAge > 0 {
+			problems = append(problems, CheckProblem{
+				Check:   CheckFreshness,
+				Message: "no backups found",
+			})
+		}
+		return problems
+	}
+
+	newest := backups[0]
+	if age := now.Sub(newest.Time); opts.MaxAge > 0 && age > opts.MaxAge {
+		problems = append(problems, CheckProblem{
+			Check: CheckFreshness,
+			Message: fmt.Sprintf("newest backup %s is %s old, older than %s",
+				newest.Key, age.Round(time.Second), opts.MaxAge),
+		})
+	}
+
+	if opts.Interval > 0 {
+		for i := 1; i < len(backups); i++ {
+			if opts.GapWindow > 0 && now.Sub(backups[i].Time) > opts.GapWindow {
+				break
+			}
+			gap := backups[i-1].Time.Sub(backups[i].Time)
+			if gap > opts.Interval {
+				problems = append(problems, CheckProblem{
+					Check: CheckGap,
+					Message: fmt.Sprintf("%s gap between backups %s and %s, larger than %s",
+						gap.Round(time.Second), backups[i].Key, backups[i-1].Key, opts.Interval),
+				})
+			}
+		}
+	}
+
+	if opts.MinSizeRatio > 0 && len(backups) > 1 {
+		previous := backups[1]
+		if float64(newest.Size) < float64(previous.Size)*opts.MinSizeRatio {
+			problems = append(problems, CheckProblem{
+				Check: CheckSize,
+				Message: fmt.Sprintf("newest backup %s is %d bytes, less than %g of previous backup %s at %d bytes",
+					newest.Key, newest.Size, opts.MinSizeRatio, previous.Key, previous.Size),
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2019, 8, 6, 12, 0, 0, 0, time.UTC)
+	backups := BackupList{
+		{Key: "newest", Time: now.Add(-2 * time.Hour), Size: 40},
+		{Key: "previous", Time: now.Add(-26 * time.Hour), Size: 100},
+		{Key: "gap", Time: now.Add(-74 * time.Hour), Size: 100},
+		{Key: "old", Time: now.Add(-30 * 24 * time.Hour), Size: 100},
+	}
+
+	tests := []struct {
+		Backups BackupList
+		Options CheckOptions
+		Checks  []string
+	}{
+		{
+			Backups: backups,
+			Options: CheckOptions{MaxAge: 3 * time.Hour},
+			Checks:  []string{},
+		},
+		{
+			Backups: backups,
+			Options: CheckOptions{MaxAge: time.Hour},
+			Checks:  []string{CheckFreshness},
+		},
+		{
+			Backups: BackupList{},
+			Options: CheckOptions{MaxAge: time.Hour},
+			Checks:  []string{CheckFreshness},
+		},
+		{
+			Backups: backups,
+			Options: CheckOptions{Interval: 25 * time.Hour, GapWindow: 7 * 24 * time.Hour},
+			Checks:  []string{CheckGap},
+		},
+		{
+			Backups: backups,
+			Options: CheckOptions{Interval: 25 * time.Hour},
+			Checks:  []string{CheckGap, CheckGap},
+		},
+		{
+			Backups: backups,
+			Options: CheckOptions{MinSizeRatio: 0.5},
+			Checks:  []string{CheckSize},
+		},
+		{
+			Backups: backups,
+			Options: CheckOptions{MinSizeRatio: 0.4},
+			Checks:  []string{},
+		},
+	}
+
+	for i, test := range tests {
+		problems := Check(test.Backups, test.Options, now)
+		if len(problems) != len(test.Checks) {
+			t.Errorf("test %d expected %d problems, got %v", i, len(test.Checks), problems)
+			continue
+		}
+		for j, p := range problems {
+			if p.Check != test.Checks[j] {
+				t.Errorf("test %d problem %d expected check %s, got %s", i, j, test.Checks[j], p.Check)
+			}
+		}
+	}
+}
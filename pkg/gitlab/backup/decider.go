@@ -1,10 +1,34 @@
 package backup
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/hashicorp/go-version"
 )
 
+type Verdict struct {
+	Keep   bool
+	Reason *Reason
+}
+
+// Decider decides which backups in a list to keep. Deciders must not hold
+// state between calls to Decide so the same decider can be evaluated any
+// number of times.
 type Decider interface {
+	Decide(BackupList) map[*Backup]Verdict
+}
+
+type DecideFn func(BackupList) map[*Backup]Verdict
+
+func (d DecideFn) Decide(l BackupList) map[*Backup]Verdict {
+	return d(l)
+}
+
+// Keeper is the original per backup decision interface. Keepers see backups
+// newest first and may keep state across a single evaluation.
+type Keeper interface {
 	Keep(*Backup) bool
 }
 
@@ -14,63 +38,373 @@ func (d DeciderFn) Keep(b *Backup) bool {
 	return d(b)
 }
 
+func (d DeciderFn) Decide(l BackupList) map[*Backup]Verdict {
+	return ExplainerFn(func(b *Backup) *Reason {
+		return newReason(d(b), "decider gave no explanation")
+	}).Decide(l)
+}
+
+// ExplainerFn decides on one backup at a time, newest first, explaining each
+// decision.
+type ExplainerFn func(*Backup) *Reason
+
+func (e ExplainerFn) Decide(l BackupList) map[*Backup]Verdict {
+	verdicts := make(map[*Backup]Verdict, len(l))
+	for _, b := range l.Sorted() {
+		reason := e(b)
+		verdicts[b] = Verdict{Keep: reason.Keep, Reason: reason}
+	}
+	return verdicts
+}
+
+func WithKeeper(k Keeper) Decider {
+	return DeciderFn(k.Keep)
+}
+
+// withState creates a decider from per backup explainers that need state,
+// such as counters, by building a fresh explainer for every evaluation.
+func withState(newExplainer func() ExplainerFn) Decider {
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		return newExplainer().Decide(l)
+	})
+}
+
+func verdictReason(v Verdict) *Reason {
+	if v.Reason == nil {
+		return newReason(v.Keep, "decider gave no explanation")
+	}
+	return v.Reason
+}
+
 func WithKeepAfterDuration(duration time.Duration) Decider {
-	return WithKeepAfterTime(time.Now().Add(-duration))
+	return withState(func() ExplainerFn {
+		after := time.Now().Add(-duration)
+		return func(b *Backup) *Reason {
+			keep := b.Time.After(after)
+			return newReason(keep, "keepAfterDuration backup time %s %s %s ago (%s)",
+				b.Time.Format(time.RFC3339), afterOrNot(keep), duration, after.Format(time.RFC3339))
+		}
+	})
 }
 
+// WithFirstKeepMatch decides with the first decider to return matchKeep for
+// a backup. Each decider only sees the backups that no earlier decider
+// matched.
 func WithFirstKeepMatch(matchKeep bool, deciders ...Decider) Decider {
-	return DeciderFn(func(b *Backup) bool {
-		for _, d := range deciders {
-			if d.Keep(b) == matchKeep {
-				return matchKeep
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		verdicts := make(map[*Backup]Verdict, len(l))
+		reasons := make(map[*Backup][]*Reason, len(l))
+		remaining := l.Sorted()
+		for i, d := range deciders {
+			decided := d.Decide(remaining)
+			next := BackupList{}
+			for _, b := range remaining {
+				reason := verdictReason(decided[b])
+				reasons[b] = append(reasons[b], reason)
+				if reason.Keep != matchKeep {
+					next = append(next, b)
+					continue
+				}
+				r := newReason(matchKeep, "keepFirstMatch decider %d matched %t", i, matchKeep)
+				r.Children = reasons[b]
+				verdicts[b] = Verdict{Keep: r.Keep, Reason: r}
 			}
+			remaining = next
+		}
+
+		for _, b := range remaining {
+			r := newReason(!matchKeep, "keepFirstMatch no decider matched %t", matchKeep)
+			r.Children = reasons[b]
+			verdicts[b] = Verdict{Keep: r.Keep, Reason: r}
 		}
-		return !matchKeep
+		return verdicts
 	})
 }
 
+// WithAggregateAgree prunes backups only when every decider agrees to prune
+// them. Each decider only sees the backups that every earlier decider agreed
+// on.
 func WithAggregateAgree(deciders ...Decider) Decider {
-	return DeciderFn(func(b *Backup) bool {
-		lastDecision := true
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		verdicts := make(map[*Backup]Verdict, len(l))
+		reasons := make(map[*Backup][]*Reason, len(l))
+		lastDecision := make(map[*Backup]bool, len(l))
+		remaining := l.Sorted()
+		for _, b := range remaining {
+			lastDecision[b] = true
+		}
+
 		for i, d := range deciders {
-			decision := d.Keep(b)
-			if i == 0 {
-				lastDecision = decision
-			}
-			if decision != lastDecision {
-				return true
+			decided := d.Decide(remaining)
+			next := BackupList{}
+			for _, b := range remaining {
+				reason := verdictReason(decided[b])
+				reasons[b] = append(reasons[b], reason)
+				if i == 0 {
+					lastDecision[b] = reason.Keep
+				}
+				if reason.Keep == lastDecision[b] {
+					next = append(next, b)
+					continue
+				}
+				r := newReason(true, "keepAggregateAgree decider %d disagreed", i)
+				r.Children = reasons[b]
+				verdicts[b] = Verdict{Keep: r.Keep, Reason: r}
 			}
+			remaining = next
+		}
+
+		for _, b := range remaining {
+			r := newReason(lastDecision[b], "keepAggregateAgree all %d deciders agreed", len(deciders))
+			r.Children = reasons[b]
+			verdicts[b] = Verdict{Keep: r.Keep, Reason: r}
 		}
-		return lastDecision
+		return verdicts
 	})
 }
 
 func WithKeepAfterTime(after time.Time) Decider {
-	return DeciderFn(func(b *Backup) bool {
-		return b.Time.After(after)
+	return ExplainerFn(func(b *Backup) *Reason {
+		keep := b.Time.After(after)
+		return newReason(keep, "keepAfterTime backup time %s %s %s",
+			b.Time.Format(time.RFC3339), afterOrNot(keep), after.Format(time.RFC3339))
 	})
 }
 
+func afterOrNot(after bool) string {
+	if after {
+		return "after"
+	}
+	return "not after"
+}
+
 func WithKeepNumberOfVersions(count int) Decider {
-	counter := map[string]struct{}{}
-	return DeciderFn(func(b *Backup) bool {
-		if len(counter) == count {
-			_, exists := counter[b.Version.String()]
-			return exists
-		}
-		counter[b.Version.String()] = struct{}{}
-		return true
+	return withState(func() ExplainerFn {
+		counter := map[string]struct{}{}
+		return func(b *Backup) *Reason {
+			_, exists := counter[b.versionKey()]
+			if exists {
+				return newReason(true, "keepNumberVersions version %s is within newest %d versions", b.versionName(), count)
+			} else if len(counter) == count {
+				return newReason(false, "keepNumberVersions version %s is not within newest %d versions", b.versionName(), count)
+			}
+			counter[b.versionKey()] = struct{}{}
+			return newReason(true, "keepNumberVersions version %s is %d/%d newest versions", b.versionName(), len(counter), count)
+		}
 	})
 }
 
 func WithKeepPerVersion(count int) Decider {
-	counter := map[string]int{}
-	return DeciderFn(func(b *Backup) bool {
-		kept := counter[b.Version.String()]
-		if kept == count {
-			return false
-		}
-		counter[b.Version.String()] = kept + 1
-		return true
+	return withState(func() ExplainerFn {
+		counter := map[string]int{}
+		return func(b *Backup) *Reason {
+			kept := counter[b.versionKey()]
+			if kept == count {
+				return newReason(false, "keepPerVersion already kept %d/%d for %s", kept, count, b.versionName())
+			}
+			counter[b.versionKey()] = kept + 1
+			return newReason(true, "keepPerVersion count %d/%d for %s", kept+1, count, b.versionName())
+		}
+	})
+}
+
+type calendarBucket struct {
+	name  string
+	limit int
+	key   func(time.Time) string
+	seen  map[string]struct{}
+}
+
+func (c *calendarBucket) claim(t time.Time) (string, bool) {
+	key := c.key(t)
+	if _, exists := c.seen[key]; exists || len(c.seen) >= c.limit {
+		return key, false
+	}
+	c.seen[key] = struct{}{}
+	return key, true
+}
+
+// WithKeepCalendarBuckets keeps the newest backup for each of the last daily
+// days, weekly ISO weeks, monthly months and yearly years that contain a
+// backup. Bucket boundaries are calculated in the supplied location.
+func WithKeepCalendarBuckets(daily, weekly, monthly, yearly int, loc *time.Location) Decider {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return withState(func() ExplainerFn {
+		return newCalendarBucketsExplainer(daily, weekly, monthly, yearly, loc)
+	})
+}
+
+func newCalendarBucketsExplainer(daily, weekly, monthly, yearly int, loc *time.Location) ExplainerFn {
+	buckets := []*calendarBucket{
+		{
+			name:  "daily",
+			limit: daily,
+			key:   func(t time.Time) string { return t.Format("2006-01-02") },
+		},
+		{
+			name:  "weekly",
+			limit: weekly,
+			key: func(t time.Time) string {
+				year, week := t.ISOWeek()
+				return fmt.Sprintf("%d-W%02d", year, week)
+			},
+		},
+		{
+			name:  "monthly",
+			limit: monthly,
+			key:   func(t time.Time) string { return t.Format("2006-01") },
+		},
+		{
+			name:  "yearly",
+			limit: yearly,
+			key:   func(t time.Time) string { return t.Format("2006") },
+		},
+	}
+	for _, bucket := range buckets {
+		bucket.seen = map[string]struct{}{}
+	}
+
+	return func(b *Backup) *Reason {
+		t := b.Time.In(loc)
+		claimed := []string{}
+		for _, bucket := range buckets {
+			if key, ok := bucket.claim(t); ok {
+				claimed = append(claimed, fmt.Sprintf("%s %s %d/%d",
+					bucket.name, key, len(bucket.seen), bucket.limit))
+			}
+		}
+		if len(claimed) == 0 {
+			return newReason(false, "keepCalendarBuckets not the newest backup in any retained bucket")
+		}
+		return newReason(true, "keepCalendarBuckets newest backup in %s", strings.Join(claimed, ", "))
+	}
+}
+
+// WithKeepWithinSize keeps the newest backups until their combined size would
+// exceed maxTotal bytes. Every backup older than the first one that does not
+// fit is pruned so the kept backups are always the newest run.
+func WithKeepWithinSize(maxTotal int64) Decider {
+	return withState(func() ExplainerFn {
+		var total int64
+		full := false
+		return func(b *Backup) *Reason {
+			if full || total+b.Size > maxTotal {
+				full = true
+				return newReason(false, "keepWithinSize backup of %d bytes does not fit in %d/%d bytes",
+					b.Size, total, maxTotal)
+			}
+			total += b.Size
+			return newReason(true, "keepWithinSize total %d/%d bytes", total, maxTotal)
+		}
+	})
+}
+
+// WithKeepVersionConstraint keeps backups whose version satisfies the
+// constraints. Versions are checked without their edition so 12.0.3-ee
+// satisfies ">= 12.0, < 13". Backups without a version are pruned.
+func WithKeepVersionConstraint(constraints version.Constraints) Decider {
+	return ExplainerFn(func(b *Backup) *Reason {
+		if b.Version == nil {
+			return newReason(false, "keepVersionConstraint unknown version cannot satisfy %s", constraints)
+		}
+		if constraints.Check(b.coreVersion()) {
+			return newReason(true, "keepVersionConstraint version %s satisfies %s", b.versionName(), constraints)
+		}
+		return newReason(false, "keepVersionConstraint version %s does not satisfy %s", b.versionName(), constraints)
+	})
+}
+
+const (
+	SeriesMajor = 1
+	SeriesMinor = 2
+)
+
+// WithKeepPerSeries keeps the newest count backups of each release series,
+// where a series is the first segments of the version, SeriesMajor for 12.x
+// or SeriesMinor for 12.0.x.
+func WithKeepPerSeries(count, segments int) Decider {
+	return withState(func() ExplainerFn {
+		counter := map[string]int{}
+		return func(b *Backup) *Reason {
+			series := "unknown version"
+			if b.Version != nil {
+				series = b.seriesName(segments) + ".x"
+			}
+			kept := counter[series]
+			if kept == count {
+				return newReason(false, "keepPerSeries already kept %d/%d for %s", kept, count, series)
+			}
+			counter[series] = kept + 1
+			return newReason(true, "keepPerSeries count %d/%d for %s", kept+1, count, series)
+		}
+	})
+}
+
+// WithKeepExpression keeps the backups the expression is true for. Times
+// are broken down in the supplied location and ages are relative to when
+// the backups are decided on. A backup the expression fails to evaluate for
+// is kept.
+func WithKeepExpression(e *Expression, loc *time.Location) Decider {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		sorted := l.Sorted()
+		env := &exprEnv{backups: sorted, loc: loc, now: time.Now()}
+		verdicts := make(map[*Backup]Verdict, len(sorted))
+		for i, b := range sorted {
+			env.index = i
+			keep, err := e.eval(env)
+			reason := newReason(keep, "keepExpression %s is %t", e, keep)
+			if err != nil {
+				reason = newReason(true, "keepExpression %s failed: %v", e, err)
+			}
+			verdicts[b] = Verdict{Keep: reason.Keep, Reason: reason}
+		}
+		return verdicts
+	})
+}
+
+// WithEdition decides on backups of the given edition with d, the decider
+// only sees those backups. Backups of any other edition, including those of
+// unknown edition, are pruned so WithEdition is usually combined with other
+// deciders using WithFirstKeepMatch.
+func WithEdition(edition string, d Decider) Decider {
+	edition = strings.ToLower(edition)
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		verdicts := make(map[*Backup]Verdict, len(l))
+		matched := BackupList{}
+		for _, b := range l {
+			if b.Edition == edition {
+				matched = append(matched, b)
+				continue
+			}
+			r := newReason(false, "edition %s is not %s", b.editionName(), edition)
+			verdicts[b] = Verdict{Keep: r.Keep, Reason: r}
+		}
+		for b, v := range d.Decide(matched) {
+			verdicts[b] = v
+		}
+		return verdicts
+	})
+}
+
+// WithGroupByEdition evaluates d separately over the backups of each edition
+// so counts such as WithKeepPerVersion apply to every edition.
+func WithGroupByEdition(d Decider) Decider {
+	return DecideFn(func(l BackupList) map[*Backup]Verdict {
+		groups := map[string]BackupList{}
+		for _, b := range l {
+			groups[b.Edition] = append(groups[b.Edition], b)
+		}
+		verdicts := make(map[*Backup]Verdict, len(l))
+		for _, group := range groups {
+			for b, v := range d.Decide(group) {
+				verdicts[b] = v
+			}
+		}
+		return verdicts
 	})
 }
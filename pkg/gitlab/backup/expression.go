@@ -0,0 +1,573 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type exprType int
+
+const (
+	exprBool exprType = iota
+	exprInt
+	exprString
+)
+
+func (t exprType) String() string {
+	switch t {
+	case exprBool:
+		return "bool"
+	case exprInt:
+		return "int"
+	}
+	return "string"
+}
+
+type exprValue struct {
+	b bool
+	i int64
+	s string
+}
+
+// exprEnv is the backup an expression is being evaluated for, at index in
+// the newest first list of backups.
+type exprEnv struct {
+	backups BackupList
+	index   int
+	loc     *time.Location
+	now     time.Time
+}
+
+type exprNode interface {
+	typ() exprType
+	eval(env *exprEnv) (exprValue, error)
+}
+
+// Expression is a type checked boolean expression over the fields of a
+// backup and its neighbours, see ParseExpression for the language.
+type Expression struct {
+	src  string
+	root exprNode
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+func (e *Expression) eval(env *exprEnv) (bool, error) {
+	v, err := e.root.eval(env)
+	return v.b, err
+}
+
+type exprField struct {
+	t   exprType
+	get func(b *Backup, env *exprEnv, i int) exprValue
+}
+
+func timeField(fn func(t time.Time) int64) exprField {
+	return exprField{exprInt, func(b *Backup, env *exprEnv, _ int) exprValue {
+		return exprValue{i: fn(b.Time.In(env.loc))}
+	}}
+}
+
+func versionSegment(segment int) exprField {
+	return exprField{exprInt, func(b *Backup, _ *exprEnv, _ int) exprValue {
+		if b.Version == nil || len(b.Version.Segments64()) <= segment {
+			return exprValue{}
+		}
+		return exprValue{i: b.Version.Segments64()[segment]}
+	}}
+}
+
+var exprFields = map[string]exprField{
+	"age": {exprInt, func(b *Backup, env *exprEnv, _ int) exprValue {
+		return exprValue{i: int64(env.now.Sub(b.Time) / time.Second)}
+	}},
+	"count": {exprInt, func(_ *Backup, env *exprEnv, _ int) exprValue {
+		return exprValue{i: int64(len(env.backups))}
+	}},
+	"day": timeField(func(t time.Time) int64 { return int64(t.Day()) }),
+	"edition": {exprString, func(b *Backup, _ *exprEnv, _ int) exprValue {
+		return exprValue{s: b.Edition}
+	}},
+	"exists": {exprBool, func(_ *Backup, _ *exprEnv, _ int) exprValue {
+		return exprValue{b: true}
+	}},
+	"hour": timeField(func(t time.Time) int64 { return int64(t.Hour()) }),
+	"index": {exprInt, func(_ *Backup, _ *exprEnv, i int) exprValue {
+		return exprValue{i: int64(i)}
+	}},
+	"key": {exprString, func(b *Backup, _ *exprEnv, _ int) exprValue {
+		return exprValue{s: b.Key}
+	}},
+	"major":   versionSegment(0),
+	"minor":   versionSegment(1),
+	"month":   timeField(func(t time.Time) int64 { return int64(t.Month()) }),
+	"patch":   versionSegment(2),
+	"quarter": timeField(func(t time.Time) int64 { return int64(t.Month()-1)/3 + 1 }),
+	"size": {exprInt, func(b *Backup, _ *exprEnv, _ int) exprValue {
+		return exprValue{i: b.Size}
+	}},
+	"time": timeField(func(t time.Time) int64 { return t.Unix() }),
+	"version": {exprString, func(b *Backup, _ *exprEnv, _ int) exprValue {
+		if b.Version == nil {
+			return exprValue{}
+		}
+		return exprValue{s: b.Version.Original()}
+	}},
+	"week": timeField(func(t time.Time) int64 {
+		_, week := t.ISOWeek()
+		return int64(week)
+	}),
+	"weekday": timeField(func(t time.Time) int64 { return int64(t.Weekday()) }),
+	"year":    timeField(func(t time.Time) int64 { return int64(t.Year()) }),
+}
+
+// exprNeighbours are the prefixes for fields of another backup, as an offset
+// in the newest first list.
+var exprNeighbours = map[string]int{
+	"next": -1,
+	"prev": 1,
+}
+
+type exprIdent struct {
+	field  exprField
+	offset int
+}
+
+func (n *exprIdent) typ() exprType {
+	return n.field.t
+}
+
+// eval gets the field, which is the zero value when the neighbour does not
+// exist.
+func (n *exprIdent) eval(env *exprEnv) (exprValue, error) {
+	i := env.index + n.offset
+	if i < 0 || i >= len(env.backups) {
+		return exprValue{}, nil
+	}
+	return n.field.get(env.backups[i], env, i), nil
+}
+
+type exprLiteral struct {
+	t exprType
+	v exprValue
+}
+
+func (n *exprLiteral) typ() exprType {
+	return n.t
+}
+
+func (n *exprLiteral) eval(_ *exprEnv) (exprValue, error) {
+	return n.v, nil
+}
+
+type exprUnary struct {
+	op string
+	x  exprNode
+}
+
+func (n *exprUnary) typ() exprType {
+	return n.x.typ()
+}
+
+func (n *exprUnary) eval(env *exprEnv) (exprValue, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return v, err
+	}
+	if n.op == "!" {
+		return exprValue{b: !v.b}, nil
+	}
+	return exprValue{i: -v.i}, nil
+}
+
+type exprBinary struct {
+	op   string
+	t    exprType
+	x, y exprNode
+}
+
+func (n *exprBinary) typ() exprType {
+	return n.t
+}
+
+func (n *exprBinary) eval(env *exprEnv) (exprValue, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return x, err
+	}
+	switch {
+	case n.op == "&&" && !x.b, n.op == "||" && x.b:
+		return x, nil
+	}
+	y, err := n.y.eval(env)
+	if err != nil {
+		return y, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return y, nil
+	case "==":
+		return exprValue{b: x == y}, nil
+	case "!=":
+		return exprValue{b: x != y}, nil
+	case "+":
+		return exprValue{i: x.i + y.i}, nil
+	case "-":
+		return exprValue{i: x.i - y.i}, nil
+	case "*":
+		return exprValue{i: x.i * y.i}, nil
+	case "/", "%":
+		if y.i == 0 {
+			return exprValue{}, errors.New("division by zero")
+		}
+		if n.op == "/" {
+			return exprValue{i: x.i / y.i}, nil
+		}
+		return exprValue{i: x.i % y.i}, nil
+	}
+
+	cmp := strings.Compare(x.s, y.s)
+	if n.x.typ() == exprInt {
+		cmp = 0
+		if x.i < y.i {
+			cmp = -1
+		} else if x.i > y.i {
+			cmp = 1
+		}
+	}
+	switch n.op {
+	case "<":
+		return exprValue{b: cmp < 0}, nil
+	case "<=":
+		return exprValue{b: cmp <= 0}, nil
+	case ">":
+		return exprValue{b: cmp > 0}, nil
+	}
+	return exprValue{b: cmp >= 0}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenOp
+	tokenString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	v    exprValue
+}
+
+var (
+	exprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")"}
+
+	exprDurations = map[byte]int64{
+		's': 1,
+		'm': 60,
+		'h': 60 * 60,
+		'd': 24 * 60 * 60,
+		'w': 7 * 24 * 60 * 60,
+	}
+)
+
+func lexExpression(src string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				i++
+			}
+			n, err := strconv.ParseInt(src[start:i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("at %d: %v", start, err)
+			}
+			if i < len(src) {
+				if unit, exists := exprDurations[src[i]]; exists {
+					n *= unit
+					i++
+				}
+			}
+			if i < len(src) && isIdentRune(rune(src[i])) {
+				return nil, fmt.Errorf("at %d: unknown duration unit in %s", start, src[start:i+1])
+			}
+			tokens = append(tokens, token{kind: tokenInt, text: src[start:i], pos: start, v: exprValue{i: n}})
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' && c == '"' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("at %d: unterminated string", start)
+			}
+			i++
+			s := src[start+1 : i-1]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(src[start:i])
+				if err != nil {
+					return nil, fmt.Errorf("at %d: %v", start, err)
+				}
+				s = unquoted
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[start:i], pos: start, v: exprValue{s: s}})
+		case isIdentRune(rune(c)):
+			start := i
+			for i < len(src) && (isIdentRune(rune(src[i])) || src[i] == '.' || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+		default:
+			op := ""
+			for _, candidate := range exprOps {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("at %d: unexpected character %q", i, c)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r))
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != tokenOp {
+		return t, false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return p.next(), true
+		}
+	}
+	return t, false
+}
+
+// binary parses a left associative chain of ops between operands parsed by
+// operand, type checking each.
+func (p *exprParser) binary(operand func() (exprNode, error), ops ...string) (exprNode, error) {
+	x, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		x, err = checkBinary(op, x, y)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func checkBinary(op token, x, y exprNode) (exprNode, error) {
+	mismatch := fmt.Errorf("at %d: %s cannot be used with %s and %s", op.pos, op.text, x.typ(), y.typ())
+	switch op.text {
+	case "&&", "||":
+		if x.typ() != exprBool || y.typ() != exprBool {
+			return nil, mismatch
+		}
+		return &exprBinary{op: op.text, t: exprBool, x: x, y: y}, nil
+	case "==", "!=":
+		if x.typ() != y.typ() {
+			return nil, mismatch
+		}
+		return &exprBinary{op: op.text, t: exprBool, x: x, y: y}, nil
+	case "<", "<=", ">", ">=":
+		if x.typ() != y.typ() || x.typ() == exprBool {
+			return nil, mismatch
+		}
+		return &exprBinary{op: op.text, t: exprBool, x: x, y: y}, nil
+	}
+	if x.typ() != exprInt || y.typ() != exprInt {
+		return nil, mismatch
+	}
+	return &exprBinary{op: op.text, t: exprInt, x: x, y: y}, nil
+}
+
+func (p *exprParser) or() (exprNode, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *exprParser) and() (exprNode, error) {
+	return p.binary(p.comparison, "&&")
+}
+
+func (p *exprParser) comparison() (exprNode, error) {
+	x, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	return checkBinary(op, x, y)
+}
+
+func (p *exprParser) additive() (exprNode, error) {
+	return p.binary(p.multiplicative, "+", "-")
+}
+
+func (p *exprParser) multiplicative() (exprNode, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	op, ok := p.accept("!", "-")
+	if !ok {
+		return p.primary()
+	}
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if (op.text == "!") != (x.typ() == exprBool) || x.typ() == exprString {
+		return nil, fmt.Errorf("at %d: %s cannot be used with %s", op.pos, op.text, x.typ())
+	}
+	return &exprUnary{op: op.text, x: x}, nil
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		return &exprLiteral{t: exprInt, v: t.v}, nil
+	case tokenString:
+		return &exprLiteral{t: exprString, v: t.v}, nil
+	case tokenIdent:
+		return identNode(t)
+	case tokenOp:
+		if t.text != "(" {
+			break
+		}
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, fmt.Errorf("at %d: expected )", p.peek().pos)
+		}
+		return x, nil
+	case tokenEOF:
+		return nil, fmt.Errorf("at %d: unexpected end of expression", t.pos)
+	}
+	return nil, fmt.Errorf("at %d: unexpected %s", t.pos, t.text)
+}
+
+func identNode(t token) (exprNode, error) {
+	switch t.text {
+	case "true", "false":
+		return &exprLiteral{t: exprBool, v: exprValue{b: t.text == "true"}}, nil
+	}
+
+	name, offset := t.text, 0
+	if i := strings.Index(name, "."); i >= 0 {
+		neighbour, exists := exprNeighbours[name[:i]]
+		if !exists {
+			return nil, fmt.Errorf("at %d: unknown backup %s, must be prev or next", t.pos, name[:i])
+		}
+		name, offset = name[i+1:], neighbour
+	}
+	field, exists := exprFields[name]
+	if !exists {
+		return nil, fmt.Errorf("at %d: unknown field %s, must be one of %s", t.pos, name, strings.Join(exprFieldNames(), ", "))
+	}
+	return &exprIdent{field: field, offset: offset}, nil
+}
+
+func exprFieldNames() []string {
+	names := make([]string, 0, len(exprFields))
+	for name := range exprFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseExpression parses and type checks a boolean expression over the
+// fields of a backup. Backups are seen newest first, with the fields of the
+// previous, older, and next, newer, backups prefixed with prev. and next.
+// Their exists field is false at either end of the list, when every other
+// field is the zero value.
+//
+// Fields are the strings key, edition and version, the ints major, minor,
+// patch, size, index, count, the unix time, its year, quarter, month, week,
+// day, weekday and hour and the age in seconds, along with the bool exists.
+// Ints can be written as durations in seconds, such as 30d, with the units
+// s, m, h, d and w. The operators are || && ! == != < <= > >= + - * / % and
+// parentheses.
+func ParseExpression(src string) (*Expression, error) {
+	tokens, err := lexExpression(src)
+	if err != nil {
+		return nil, fmt.Errorf("parsing expression %v", err)
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("parsing expression %v", err)
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("parsing expression at %d: unexpected %s", t.pos, t.text)
+	}
+	if root.typ() != exprBool {
+		return nil, fmt.Errorf("parsing expression: must be bool, not %s", root.typ())
+	}
+	return &Expression{src: src, root: root}, nil
+}
@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		Expression string
+		ShouldFail bool
+	}{
+		{Expression: "true"},
+		{Expression: "(index + 1) % 7 == 0 || !(age > 90d)"},
+		{Expression: `edition == "ee" && key != 'latest.tar'`},
+		{Expression: "prev.exists && prev.minor < minor && next.major >= -1"},
+		{Expression: "version > \"12.0\""},
+		{Expression: "", ShouldFail: true},
+		{Expression: "size", ShouldFail: true},
+		{Expression: "size > \"10\"", ShouldFail: true},
+		{Expression: "edition && true", ShouldFail: true},
+		{Expression: "!size", ShouldFail: true},
+		{Expression: "-edition == edition", ShouldFail: true},
+		{Expression: "true == 1", ShouldFail: true},
+		{Expression: "true < false", ShouldFail: true},
+		{Expression: "versoin == \"12.0.3\"", ShouldFail: true},
+		{Expression: "prev.prev.exists", ShouldFail: true},
+		{Expression: "other.exists", ShouldFail: true},
+		{Expression: "age > 30days", ShouldFail: true},
+		{Expression: "(true", ShouldFail: true},
+		{Expression: "true true", ShouldFail: true},
+		{Expression: "edition == \"ee", ShouldFail: true},
+		{Expression: "size > 1 = 2", ShouldFail: true},
+		{Expression: "exec(\"rm\")", ShouldFail: true},
+	}
+
+	for i, test := range tests {
+		_, err := ParseExpression(test.Expression)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+	}
+}
+
+func TestExpressionFields(t *testing.T) {
+	newer := &Backup{
+		Key:     "newer.tar",
+		Edition: "ee",
+		Size:    20,
+		Time:    time.Date(2019, 8, 6, 23, 30, 0, 0, time.UTC),
+		Version: version.Must(version.NewVersion("12.1.0")),
+	}
+	older := &Backup{
+		Key:  "older.tar",
+		Size: 10,
+		Time: time.Date(2019, 3, 31, 12, 0, 0, 0, time.UTC),
+	}
+	loc := time.FixedZone("UTC+1", 60*60)
+	env := &exprEnv{
+		backups: BackupList{newer, older},
+		loc:     loc,
+		now:     newer.Time.Add(time.Hour),
+	}
+
+	tests := []string{
+		`key == "newer.tar" && edition == "ee" && size == 20 && version == "12.1.0"`,
+		"major == 12 && minor == 1 && patch == 0",
+		"year == 2019 && quarter == 3 && month == 8 && day == 7 && hour == 0 && weekday == 3 && week == 32",
+		"age == 1h && time == 1565134200 && index == 0 && count == 2",
+		"!next.exists && next.size == 0 && next.key == \"\"",
+		"prev.exists && prev.index == 1 && prev.quarter == 1 && prev.major == 0 && prev.version == \"\"",
+		"size - prev.size * 2 == 0 && size / 3 == 6 && size % 3 == 2",
+	}
+	for i, test := range tests {
+		keep, err := mustExpression(test).eval(env)
+		if err != nil {
+			t.Fatalf("unexpected error evaluating test %d: %v", i, err)
+		}
+		if !keep {
+			t.Errorf("test %d expected %s to be true", i, test)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+// Listing is every object in a bucket sorted into parsed backups, newest
+// first, objects that looked like backups but failed to parse and objects
+// that are not backups.
+type Listing struct {
+	Backups    BackupList
+	Unparsable []*UnparsableObject
+	NonBackup  []*Object
+}
+
+func ListBackups(ctx context.Context, bucket *blob.Bucket, parser KeyParser) (*Listing, error) {
+	return ListBackupsWithScope(ctx, bucket, parser, Scope{})
+}
+
+// ListBackupsWithScope lists only the objects within scope. Keys are parsed
+// with the scope prefix removed but are otherwise left whole.
+func ListBackupsWithScope(ctx context.Context, bucket *blob.Bucket, parser KeyParser, scope Scope) (*Listing, error) {
+	if parser == nil {
+		parser = WithGitLabKeyParser()
+	}
+
+	logger := logging.FromContext(ctx)
+	it := bucket.List(&blob.ListOptions{
+		Prefix:    scope.Prefix,
+		Delimiter: scope.Delimiter,
+	})
+	var (
+		listing = &Listing{
+			Backups:    BackupList{},
+			Unparsable: []*UnparsableObject{},
+			NonBackup:  []*Object{},
+		}
+		err error
+		obj *blob.ListObject
+	)
+	for obj, err = it.Next(ctx); obj != nil && err == nil; obj, err = it.Next(ctx) {
+		if obj.IsDir {
+			logger.Debugf("skipping directory %s", obj.Key)
+			continue
+		} else if !scope.contains(obj.Key) {
+			logger.Debugf("skipping %s outside of scope", obj.Key)
+			continue
+		}
+
+		object := Object{
+			Key:     obj.Key,
+			MD5:     obj.MD5,
+			ModTime: obj.ModTime,
+			Size:    obj.Size,
+		}
+		b, perr := parser.Parse(scope.name(obj.Key))
+		if perr == ErrUnrecognisedKey {
+			logger.Debugf("%s is not a backup", obj.Key)
+			listing.NonBackup = append(listing.NonBackup, &object)
+			continue
+		} else if perr != nil {
+			logger.Debugf("%s is an unparsable backup: %v", obj.Key, perr)
+			listing.Unparsable = append(listing.Unparsable, &UnparsableObject{
+				Object: object,
+				Err:    perr,
+			})
+			continue
+		}
+		b.Key = obj.Key
+		b.MD5 = obj.MD5
+		b.ModTime = obj.ModTime
+		b.Size = obj.Size
+		if b.Time.IsZero() {
+			b.Time = obj.ModTime
+		}
+		logger.Debugf("%s is a backup from %s", obj.Key, b.Time.Format(time.RFC3339))
+		listing.Backups = append(listing.Backups, b)
+	}
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("getting backup list: %v", err)
+	}
+
+	sort.Sort(listing.Backups)
+	return listing, nil
+}
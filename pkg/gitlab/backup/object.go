@@ -0,0 +1,60 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+type Object struct {
+	Key     string
+	MD5     []byte
+	ModTime time.Time
+	Size    int64
+}
+
+type UnparsableObject struct {
+	Object
+	Err error
+}
+
+type ObjectAction string
+
+const (
+	ObjectActionIgnore     ObjectAction = "ignore"
+	ObjectActionWarn       ObjectAction = "warn"
+	ObjectActionFail       ObjectAction = "fail"
+	ObjectActionPruneAfter ObjectAction = "prune-after"
+)
+
+// ObjectPolicy describes what to do with bucket objects that are not
+// backups. Objects are only pruned by ObjectActionPruneAfter once their
+// modification time is older than After.
+type ObjectPolicy struct {
+	Action ObjectAction
+	After  time.Duration
+}
+
+func (a ObjectAction) Valid() bool {
+	switch a {
+	case ObjectActionIgnore, ObjectActionWarn, ObjectActionFail, ObjectActionPruneAfter:
+		return true
+	}
+	return false
+}
+
+// apply returns true when the object should be pruned or an error when the
+// policy requires the run to fail.
+func (p ObjectPolicy) apply(logger *logging.Logger, obj *Object, reason string, now time.Time) (bool, error) {
+	switch p.Action {
+	case ObjectActionIgnore:
+	case ObjectActionFail:
+		return false, fmt.Errorf("%s %s", reason, obj.Key)
+	case ObjectActionPruneAfter:
+		return obj.ModTime.Before(now.Add(-p.After)), nil
+	default:
+		logger.Warnf("%s %s", reason, obj.Key)
+	}
+	return false, nil
+}
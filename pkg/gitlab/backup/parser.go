@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	GroupEdition = "edition"
+	GroupTime    = "time"
+	GroupVersion = "version"
+
+	TimeLayoutUnix = "unix"
+)
+
+var (
+	// ErrUnrecognisedKey is returned by a KeyParser when a key is not in a
+	// format the parser understands, as opposed to being in the right format
+	// but malformed.
+	ErrUnrecognisedKey = errors.New("key is not a recognised backup format")
+)
+
+type KeyParser interface {
+	Parse(key string) (*Backup, error)
+}
+
+type KeyParserFn func(key string) (*Backup, error)
+
+func (p KeyParserFn) Parse(key string) (*Backup, error) {
+	return p(key)
+}
+
+type regexpKeyParser struct {
+	expr       *regexp.Regexp
+	timeLayout string
+	baseName   bool
+}
+
+func (p *regexpKeyParser) Parse(key string) (*Backup, error) {
+	name := key
+	if p.baseName {
+		name = path.Base(key)
+	}
+
+	matches := p.expr.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, ErrUnrecognisedKey
+	}
+
+	groups := map[string]string{}
+	for i, group := range p.expr.SubexpNames() {
+		if group != "" {
+			groups[group] = matches[i]
+		}
+	}
+
+	b := &Backup{
+		Key: key,
+	}
+	if raw, exists := groups[GroupTime]; exists {
+		t, err := parseKeyTime(raw, p.timeLayout)
+		if err != nil {
+			return nil, err
+		}
+		b.Time = t
+	}
+	b.Edition = strings.ToLower(groups[GroupEdition])
+	if raw, exists := groups[GroupVersion]; exists {
+		if b.Edition == "" {
+			raw, b.Edition = splitEdition(raw)
+		}
+		ver, err := version.NewVersion(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing backup version %s: %v", raw, err)
+		}
+		b.Version = ver
+	}
+	return b, nil
+}
+
+// splitEdition separates a trailing edition, such as the ee in 12.0.3-ee,
+// from a version. Only an all letter suffix is taken as the edition so pre
+// releases like 12.0.0-rc1 are left intact.
+func splitEdition(raw string) (string, string) {
+	i := strings.LastIndex(raw, "-")
+	if i < 0 {
+		return raw, ""
+	}
+	edition := raw[i+1:]
+	if edition == "" {
+		return raw, ""
+	}
+	for _, r := range edition {
+		if !unicode.IsLetter(r) {
+			return raw, ""
+		}
+	}
+	return raw[:i], strings.ToLower(edition)
+}
+
+func parseKeyTime(raw, layout string) (time.Time, error) {
+	if layout == "" || layout == TimeLayoutUnix {
+		unixTime, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("converting backup time %s to unix int: %v", raw, err)
+		}
+		return time.Unix(unixTime, 0), nil
+	}
+
+	t, err := time.Parse(layout, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing backup time %s: %v", raw, err)
+	}
+	return t, nil
+}
+
+// NewRegexpKeyParser creates a KeyParser from a regular expression matched
+// against the full object key. The named groups time, version and edition
+// are used to populate the backup when present. Time is parsed with the
+// supplied layout, or as seconds since the unix epoch for "unix".
+func NewRegexpKeyParser(expr, timeLayout string) (KeyParser, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("compiling key parser expression: %v", err)
+	}
+
+	known := map[string]bool{GroupEdition: true, GroupTime: true, GroupVersion: true}
+	for _, group := range re.SubexpNames() {
+		if group != "" && !known[group] {
+			return nil, fmt.Errorf("unknown key parser expression group %s", group)
+		}
+	}
+	return &regexpKeyParser{
+		expr:       re,
+		timeLayout: timeLayout,
+	}, nil
+}
+
+func builtinKeyParser(expr string) KeyParser {
+	return &regexpKeyParser{
+		expr:     regexp.MustCompile(expr),
+		baseName: true,
+	}
+}
+
+// WithTimestampKeyParser parses the original <timestamp>_gitlab_backup.tar
+// naming scheme.
+func WithTimestampKeyParser() KeyParser {
+	return builtinKeyParser(`^(?P<time>\d+)_gitlab_backup\.tar$`)
+}
+
+// WithTimestampVersionKeyParser parses the GitLab 8.x
+// <timestamp>_<version>_gitlab_backup.tar naming scheme.
+func WithTimestampVersionKeyParser() KeyParser {
+	return builtinKeyParser(`^(?P<time>\d+)_(?P<version>[^_]+)_gitlab_backup\.tar$`)
+}
+
+// WithTimestampDateKeyParser parses the
+// <timestamp>_<yyyy>_<mm>_<dd>_gitlab_backup.tar naming scheme.
+func WithTimestampDateKeyParser() KeyParser {
+	return builtinKeyParser(`^(?P<time>\d+)_\d{4}_\d{2}_\d{2}_gitlab_backup\.tar$`)
+}
+
+// WithTimestampDateVersionKeyParser parses the current
+// <timestamp>_<yyyy>_<mm>_<dd>_<version>_gitlab_backup.tar naming scheme.
+func WithTimestampDateVersionKeyParser() KeyParser {
+	return builtinKeyParser(`^(?P<time>\d+)_\d{4}_\d{2}_\d{2}_(?P<version>[^_]+)_gitlab_backup\.tar$`)
+}
+
+// WithCustomNameKeyParser parses backups created with BACKUP=<name>. These
+// carry neither a time or version in their key so the backup time is left
+// unset to be filled in from the object modification time.
+func WithCustomNameKeyParser() KeyParser {
+	return builtinKeyParser(`^.+_gitlab_backup\.tar$`)
+}
+
+// WithFirstKeyParser tries each parser in order returning the first parsed
+// backup. Parsing stops at the first parser to recognise but fail to parse a
+// key.
+func WithFirstKeyParser(parsers ...KeyParser) KeyParser {
+	return KeyParserFn(func(key string) (*Backup, error) {
+		for _, p := range parsers {
+			b, err := p.Parse(key)
+			if err == ErrUnrecognisedKey {
+				continue
+			}
+			return b, err
+		}
+		return nil, ErrUnrecognisedKey
+	})
+}
+
+// WithGitLabKeyParser parses every naming scheme GitLab has used for its
+// backups. The edition is taken from a suffix on the version such as
+// 12.0.3-ee, backups without one have an unknown edition.
+func WithGitLabKeyParser() KeyParser {
+	return WithFirstKeyParser(
+		WithTimestampDateVersionKeyParser(),
+		WithTimestampDateKeyParser(),
+		WithTimestampVersionKeyParser(),
+		WithTimestampKeyParser(),
+		WithCustomNameKeyParser(),
+	)
+}
@@ -0,0 +1,193 @@
+package backup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+)
+
+func TestWithGitLabKeyParser(t *testing.T) {
+	tests := []struct {
+		Key          string
+		Time         time.Time
+		Version      string
+		Edition      string
+		Unrecognised bool
+		ShouldFail   bool
+	}{
+		{
+			Key:     "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			Time:    time.Unix(1565056820, 0),
+			Version: "12.0.3",
+			Edition: "ee",
+		},
+		{
+			Key:     "1565056820_2019_08_06_12.0.0-rc1-CE_gitlab_backup.tar",
+			Time:    time.Unix(1565056820, 0),
+			Version: "12.0.0-rc1",
+			Edition: "ce",
+		},
+		{
+			Key:     "1565056820_2019_08_06_12.0.0-rc1_gitlab_backup.tar",
+			Time:    time.Unix(1565056820, 0),
+			Version: "12.0.0-rc1",
+		},
+		{
+			Key:     "gitlab-prod/1565056820_2019_08_06_12.0.3_gitlab_backup.tar",
+			Time:    time.Unix(1565056820, 0),
+			Version: "12.0.3",
+		},
+		{
+			Key:  "1493107454_2017_04_25_gitlab_backup.tar",
+			Time: time.Unix(1493107454, 0),
+		},
+		{
+			Key:     "1461841210_8.7.0_gitlab_backup.tar",
+			Time:    time.Unix(1461841210, 0),
+			Version: "8.7.0",
+		},
+		{
+			Key:  "1393513186_gitlab_backup.tar",
+			Time: time.Unix(1393513186, 0),
+		},
+		{
+			Key: "nightly_gitlab_backup.tar",
+		},
+		{
+			Key:        "1565056820_2019_08_06_not!a!version_gitlab_backup.tar",
+			ShouldFail: true,
+		},
+		{
+			Key:          "1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar.part",
+			Unrecognised: true,
+		},
+		{
+			Key:          "README.md",
+			Unrecognised: true,
+		},
+	}
+
+	parser := WithGitLabKeyParser()
+	for i, test := range tests {
+		b, err := parser.Parse(test.Key)
+		if test.Unrecognised {
+			if err != ErrUnrecognisedKey {
+				t.Errorf("expected test %d key %s to be unrecognised, got %v", i, test.Key, err)
+			}
+			continue
+		}
+		if err != nil && test.ShouldFail {
+			continue
+		} else if err == nil && test.ShouldFail {
+			t.Errorf("expected test %d key %s to fail parsing", i, test.Key)
+			continue
+		} else if err != nil {
+			t.Errorf("unexpected error for test %d key %s: %v", i, test.Key, err)
+			continue
+		}
+
+		if b.Key != test.Key {
+			t.Errorf("test %d backup key %s does not match %s", i, b.Key, test.Key)
+		}
+		if !b.Time.Equal(test.Time) {
+			t.Errorf("test %d backup time %v does not match %v", i, b.Time, test.Time)
+		}
+		ver := ""
+		if b.Version != nil {
+			ver = b.Version.Original()
+		}
+		if ver != test.Version {
+			t.Errorf("test %d backup version %s does not match %s", i, ver, test.Version)
+		}
+		if b.Edition != test.Edition {
+			t.Errorf("test %d backup edition %s does not match %s", i, b.Edition, test.Edition)
+		}
+	}
+}
+
+func TestRegexpKeyParser(t *testing.T) {
+	parser, err := NewRegexpKeyParser(
+		`^backups/(?P<time>\d{4}-\d{2}-\d{2})-(?P<version>[\d.]+)-(?P<edition>ce|ee)\.tar$`,
+		"2006-01-02")
+	if err != nil {
+		t.Fatalf("unexpected error creating regexp key parser: %v", err)
+	}
+
+	b, err := parser.Parse("backups/2019-08-06-12.0.3-ee.tar")
+	if err != nil {
+		t.Fatalf("unexpected error parsing key: %v", err)
+	}
+	if !b.Time.Equal(time.Date(2019, 8, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("backup time %v does not match expected", b.Time)
+	}
+	if b.Version.Original() != "12.0.3" || b.Edition != "ee" {
+		t.Errorf("backup version %s edition %s does not match expected", b.Version.Original(), b.Edition)
+	}
+
+	if _, err := parser.Parse("2019-08-06-12.0.3-ee.tar"); err != ErrUnrecognisedKey {
+		t.Errorf("expected key outside of pattern to be unrecognised, got %v", err)
+	}
+
+	if _, err := NewRegexpKeyParser(`^(?P<unknown>.+)$`, ""); err == nil {
+		t.Error("expected regexp key parser with unknown group to fail")
+	}
+}
+
+func TestCreatePruneListWithParser(t *testing.T) {
+	tests := []struct {
+		Parser     KeyParser
+		PreFiles   []string
+		PruneFiles []string
+	}{
+		{
+			Parser: WithGitLabKeyParser(),
+			PreFiles: []string{
+				"1393513186_gitlab_backup.tar",
+				"1461841210_8.7.0_gitlab_backup.tar",
+				"1493107454_2017_04_25_gitlab_backup.tar",
+				"gitlab/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+				"README.md",
+			},
+			PruneFiles: []string{
+				"1393513186_gitlab_backup.tar",
+				"1461841210_8.7.0_gitlab_backup.tar",
+				"1493107454_2017_04_25_gitlab_backup.tar",
+			},
+		},
+		{
+			Parser: WithTimestampDateVersionKeyParser(),
+			PreFiles: []string{
+				"1393513186_gitlab_backup.tar",
+				"1461841210_8.7.0_gitlab_backup.tar",
+				"1493107454_2017_04_25_gitlab_backup.tar",
+				"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+			},
+			PruneFiles: []string{},
+		},
+	}
+
+	for i, test := range tests {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+
+		if err := createDummyFiles(bucket, test.PreFiles); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		res, err := CreatePruneListWithOptions(context.Background(), bucket,
+			WithKeepAfterTime(time.Date(2019, 01, 01, 0, 0, 0, 0, time.UTC)),
+			&PruneOptions{Parser: test.Parser})
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		if !comparePruneLists(res.Prune, test.PruneFiles) {
+			t.Errorf("test %d prune list does not match expected post list", i)
+		}
+	}
+}
@@ -3,69 +3,270 @@ package backup
 import (
 	"context"
 	"fmt"
-	"io"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/hashicorp/go-version"
-
 	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
 )
 
-func CreatePruneList(bucket *blob.Bucket, d Decider) ([]string, error) {
-	it := bucket.List(&blob.ListOptions{})
-	ctx := context.Background()
-	var (
-		backups BackupList = BackupList{}
-		err     error
-		obj     *blob.ListObject
-	)
-	for obj, err = it.Next(ctx); obj != nil && err == nil; obj, err = it.Next(ctx) {
-		if obj.IsDir {
-			continue
-		}
+type PruneOptions struct {
+	Parser     KeyParser
+	Unparsable ObjectPolicy
+	NonBackup  ObjectPolicy
+	Safety     Safety
+	Scope      Scope
+}
 
-		parts := strings.Split(obj.Key, "_")
-		if len(parts) != 7 {
-			continue
-		}
-		unixTime, err := strconv.ParseInt(parts[0], 10, 64)
+type PruneResult struct {
+	Listing
+	Verdicts map[*Backup]Verdict
+	Prune    []string
+}
+
+func CreatePruneList(bucket *blob.Bucket, d Decider) (*PruneResult, error) {
+	return CreatePruneListWithOptions(context.Background(), bucket, d, &PruneOptions{})
+}
+
+// CreatePruneListWithOptions lists the bucket and decides which objects to
+// prune. When the prune list exceeds a safety limit a *DeleteLimitError is
+// returned along with the result so the offending list can be inspected.
+func CreatePruneListWithOptions(ctx context.Context, bucket *blob.Bucket, d Decider, opts *PruneOptions) (*PruneResult, error) {
+	unparsablePolicy := opts.Unparsable
+	if unparsablePolicy.Action == "" {
+		unparsablePolicy.Action = ObjectActionWarn
+	}
+	nonBackupPolicy := opts.NonBackup
+	if nonBackupPolicy.Action == "" {
+		nonBackupPolicy.Action = ObjectActionIgnore
+	}
+
+	listing, err := ListBackupsWithScope(ctx, bucket, opts.Parser, opts.Scope)
+	if err != nil {
+		return nil, err
+	}
+	result := &PruneResult{
+		Listing:  *listing,
+		Verdicts: map[*Backup]Verdict{},
+		Prune:    []string{},
+	}
+
+	logger := logging.FromContext(ctx)
+	now := time.Now()
+	for _, obj := range result.NonBackup {
+		prune, err := nonBackupPolicy.apply(logger, obj, "object is not a backup", now)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert backup time to unix int: %v", err)
+			return nil, err
+		} else if prune {
+			result.Prune = append(result.Prune, obj.Key)
 		}
-		ver, err := version.NewVersion(parts[4])
+	}
+	for _, obj := range result.Unparsable {
+		reason := fmt.Sprintf("unparsable backup (%v)", obj.Err)
+		prune, err := unparsablePolicy.apply(logger, &obj.Object, reason, now)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse backup version %s: %v", parts[4], err)
+			return nil, err
+		} else if prune {
+			result.Prune = append(result.Prune, obj.Key)
 		}
-		backups = append(backups, &Backup{
-			Key:     obj.Key,
-			Time:    time.Unix(unixTime, 0),
-			Version: ver,
-		})
-	}
-	if err != nil && err != io.EOF {
-		return nil, fmt.Errorf("getting backup list: %v", err)
 	}
 
-	rval := []string{}
-	sort.Sort(backups)
-	for _, b := range backups {
-		if !d.Keep(b) {
-			rval = append(rval, b.Key)
+	verdicts := d.Decide(result.Backups)
+	for _, b := range result.Backups {
+		reason := verdictReason(verdicts[b])
+		result.Verdicts[b] = Verdict{Keep: reason.Keep, Reason: reason}
+	}
+	opts.Safety.applyMinKeep(result.Backups, result.Verdicts)
+	for _, b := range result.Backups {
+		verdict := result.Verdicts[b]
+		if verdict.Reason != nil {
+			logger.Debugf("%s %s: %s", b.Key, verdict.Reason.Verdict(), verdict.Reason.Message)
+		}
+		if !verdict.Keep {
+			result.Prune = append(result.Prune, b.Key)
 		}
 	}
 
-	return rval, nil
+	if err := opts.Safety.checkLimits(result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+type DeleteOptions struct {
+	// ContinueOnError deletes the rest of the prune list after a failed
+	// deletion, returning a *DeleteError listing every failure.
+	ContinueOnError bool
+	// Deleted, when set, is called after every attempted deletion with the
+	// error from the attempt. It is called concurrently when there is more
+	// than one worker.
+	Deleted func(key string, err error)
+	// MaxDeleteCount refuses to delete anything when the prune list is
+	// longer, zero disables the check.
+	MaxDeleteCount int
+	// Quarantine, when set, has backups moved into it instead of deleted.
+	Quarantine *Quarantine
+	// Retries is how many times a deletion failing with a transient error
+	// is retried, waiting RetryBackoff before the first retry and doubling
+	// the wait for each after.
+	Retries      int
+	RetryBackoff time.Duration
+	// Scope refuses to delete anything when the prune list has a key
+	// outside of the scope prefix.
+	Scope Scope
+	// Workers is the number of deletions made at once, defaulting to one.
+	Workers int
+}
+
+// KeyFailure is an object that could not be deleted, moved or restored.
+type KeyFailure struct {
+	Key string
+	Err error
+}
+
+func failuresError(verb string, failures []*KeyFailure) string {
+	msgs := make([]string, len(failures))
+	for i, f := range failures {
+		msgs[i] = fmt.Sprintf("%s: %v", f.Key, f.Err)
+	}
+	return fmt.Sprintf("failed %s %d backups: %s", verb, len(failures), strings.Join(msgs, "; "))
+}
+
+// DeleteError lists every backup that failed to delete when continuing on
+// error.
+type DeleteError struct {
+	Failures []*KeyFailure
+}
+
+func (e *DeleteError) Error() string {
+	return failuresError("removing", e.Failures)
 }
 
 func DeletePruneList(bucket *blob.Bucket, pruneList []string) error {
-	ctx := context.Background()
-	for _, b := range pruneList {
-		if err := bucket.Delete(ctx, b); err != nil {
-			return fmt.Errorf("removing backup %s: %v", b, err)
+	return DeletePruneListWithOptions(context.Background(), bucket, pruneList, &DeleteOptions{})
+}
+
+// DeletePruneListWithOptions deletes each key in the prune list, stopping
+// before the next deletion once the context is done. Unless continuing on
+// error no further deletions are started after the first failure.
+func DeletePruneListWithOptions(ctx context.Context, bucket *blob.Bucket, pruneList []string, opts *DeleteOptions) error {
+	if err := (Safety{MaxDeleteCount: opts.MaxDeleteCount}).checkCount(len(pruneList)); err != nil {
+		return err
+	}
+	if err := opts.Scope.checkKeys(pruneList); err != nil {
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		lock     sync.Mutex
+		failures []*KeyFailure
+		stopErr  error
+		wg       sync.WaitGroup
+		keys     = make(chan string)
+	)
+	// next reports whether key should be deleted, recording why not when the
+	// context is done.
+	next := func(key string) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		if stopErr != nil || (len(failures) > 0 && !opts.ContinueOnError) {
+			return false
 		}
+		if err := ctx.Err(); err != nil {
+			stopErr = fmt.Errorf("stopping deletion before backup %s: %v", key, err)
+			return false
+		}
+		return true
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				if !next(key) {
+					continue
+				}
+				var err error
+				if opts.Quarantine != nil {
+					err = opts.Quarantine.move(ctx, bucket, key, opts)
+				} else {
+					err = retry(ctx, opts, func() error {
+						return bucket.Delete(ctx, key)
+					})
+				}
+				if opts.Deleted != nil {
+					opts.Deleted(key, err)
+				}
+				if err != nil {
+					logger.Warnf("removing backup %s failed: %v", key, err)
+				} else {
+					logger.Debugf("removed backup %s", key)
+				}
+				if err != nil {
+					lock.Lock()
+					failures = append(failures, &KeyFailure{Key: key, Err: err})
+					lock.Unlock()
+				}
+			}
+		}()
+	}
+	for _, key := range pruneList {
+		if !next(key) {
+			break
+		}
+		keys <- key
+	}
+	close(keys)
+	wg.Wait()
+
+	if len(failures) > 0 && !opts.ContinueOnError {
+		return fmt.Errorf("removing backup %s: %v", failures[0].Key, failures[0].Err)
+	} else if len(failures) > 0 {
+		sort.Slice(failures, func(i, j int) bool {
+			return failures[i].Key < failures[j].Key
+		})
+		return &DeleteError{Failures: failures}
+	}
+	return stopErr
+}
+
+// retry calls fn until it succeeds, fails with an error that is not
+// transient or runs out of retries.
+func retry(ctx context.Context, opts *DeleteOptions, fn func() error) error {
+	backoff := opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= opts.Retries || !transientError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		logging.FromContext(ctx).Debugf("retrying in %s after transient error: %v", backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// transientError reports whether err is a provider error worth retrying.
+func transientError(err error) bool {
+	switch gcerrors.Code(err) {
+	case gcerrors.DeadlineExceeded, gcerrors.Internal, gcerrors.ResourceExhausted, gcerrors.Unknown:
+		return true
 	}
-	return nil
+	return false
 }
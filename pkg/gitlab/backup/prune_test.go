@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/gcerrors"
+)
+
+var (
+	errTransient = errors.New("transient error")
+	errPermanent = errors.New("permanent error")
+)
+
+// flakyBucket is a driver that only supports deletion, failing each key a
+// set number of times before succeeding.
+type flakyBucket struct {
+	driver.Bucket
+
+	lock     sync.Mutex
+	failures map[string]int
+	attempts map[string]int
+	deleted  map[string]bool
+}
+
+func newFlakyBucket(failures map[string]int) (*flakyBucket, *blob.Bucket) {
+	drv := &flakyBucket{
+		failures: failures,
+		attempts: map[string]int{},
+		deleted:  map[string]bool{},
+	}
+	return drv, blob.NewBucket(drv)
+}
+
+func (b *flakyBucket) Delete(ctx context.Context, key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.attempts[key]++
+	if b.failures[key] < 0 {
+		return errPermanent
+	} else if b.attempts[key] <= b.failures[key] {
+		return errTransient
+	}
+	b.deleted[key] = true
+	return nil
+}
+
+func (b *flakyBucket) ErrorCode(err error) gcerrors.ErrorCode {
+	if err == errTransient {
+		return gcerrors.ResourceExhausted
+	}
+	return gcerrors.PermissionDenied
+}
+
+func (b *flakyBucket) Close() error {
+	return nil
+}
+
+func TestDeleteRetriesAndContinues(t *testing.T) {
+	drv, bucket := newFlakyBucket(map[string]int{
+		"b": 2,
+		"d": -1,
+		"f": 5,
+	})
+	defer bucket.Close()
+
+	deleted := 0
+	var lock sync.Mutex
+	err := DeletePruneListWithOptions(context.Background(), bucket,
+		[]string{"a", "b", "c", "d", "e", "f"}, &DeleteOptions{
+			ContinueOnError: true,
+			Deleted: func(key string, err error) {
+				lock.Lock()
+				defer lock.Unlock()
+				if err == nil {
+					deleted++
+				}
+			},
+			Retries:      2,
+			RetryBackoff: time.Millisecond,
+			Workers:      3,
+		})
+
+	deleteErr, ok := err.(*DeleteError)
+	if !ok {
+		t.Fatalf("expected delete error, got %v", err)
+	}
+	if len(deleteErr.Failures) != 2 || deleteErr.Failures[0].Key != "d" || deleteErr.Failures[1].Key != "f" {
+		t.Errorf("expected keys d and f to fail, got %v", deleteErr)
+	}
+	if drv.attempts["d"] != 1 {
+		t.Errorf("expected permanent failure to not be retried, attempted %d times", drv.attempts["d"])
+	}
+	if drv.attempts["f"] != 3 {
+		t.Errorf("expected transient failure to be retried twice, attempted %d times", drv.attempts["f"])
+	}
+	for _, key := range []string{"a", "b", "c", "e"} {
+		if !drv.deleted[key] {
+			t.Errorf("expected key %s to be deleted", key)
+		}
+	}
+	if deleted != 4 {
+		t.Errorf("expected 4 successful deletions to be reported, got %d", deleted)
+	}
+}
+
+func TestDeleteStopsOnError(t *testing.T) {
+	drv, bucket := newFlakyBucket(map[string]int{
+		"b": -1,
+	})
+	defer bucket.Close()
+
+	err := DeletePruneListWithOptions(context.Background(), bucket,
+		[]string{"a", "b", "c"}, &DeleteOptions{})
+	if err == nil {
+		t.Fatal("expected deletion to fail")
+	}
+	if _, aggregated := err.(*DeleteError); aggregated {
+		t.Errorf("expected first error only without continue on error, got %v", err)
+	}
+	if !drv.deleted["a"] || drv.attempts["c"] != 0 {
+		t.Errorf("expected deletion to stop after key b, attempts %v", drv.attempts)
+	}
+}
+
+func TestDeleteStopsOnContextDone(t *testing.T) {
+	drv, bucket := newFlakyBucket(map[string]int{
+		"a": 100,
+	})
+	defer bucket.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := DeletePruneListWithOptions(ctx, bucket, []string{"a", "b"}, &DeleteOptions{
+		Retries:      100,
+		RetryBackoff: 10 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected deletion to fail once the context is done")
+	}
+	if drv.attempts["b"] != 0 {
+		t.Error("expected no deletions to start after the context is done")
+	}
+}
@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+)
+
+// Quarantine is where pruned backups are moved to rather than being deleted
+// outright. Moved backups keep their key under Prefix and can be purged once
+// they have been quarantined for long enough.
+type Quarantine struct {
+	Bucket *blob.Bucket
+	Prefix string
+	// KeyPrefix limits listing and purging to backups whose original key
+	// starts with it, such as the prefix of the bucket they came from.
+	KeyPrefix string
+}
+
+func (q *Quarantine) key(key string) string {
+	return q.Prefix + key
+}
+
+// move copies the object into the quarantine and then deletes the original.
+// The original is left in place when the copy fails.
+func (q *Quarantine) move(ctx context.Context, bucket *blob.Bucket, key string, opts *DeleteOptions) error {
+	err := retry(ctx, opts, func() error {
+		return copyObject(ctx, bucket, key, q.Bucket, q.key(key))
+	})
+	if err != nil {
+		return fmt.Errorf("copying to quarantine: %v", err)
+	}
+	return retry(ctx, opts, func() error {
+		return bucket.Delete(ctx, key)
+	})
+}
+
+// copyObject copies an object and its metadata between buckets, which may
+// be the same bucket.
+func copyObject(ctx context.Context, src *blob.Bucket, srcKey string, dst *blob.Bucket, dstKey string) error {
+	attrs, err := src.Attributes(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	r, err := src.NewReader(ctx, srcKey, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// Cancelling the writer context before closing discards a partial write.
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := dst.NewWriter(writeCtx, dstKey, &blob.WriterOptions{
+		CacheControl:       attrs.CacheControl,
+		ContentDisposition: attrs.ContentDisposition,
+		ContentEncoding:    attrs.ContentEncoding,
+		ContentLanguage:    attrs.ContentLanguage,
+		ContentType:        attrs.ContentType,
+		Metadata:           attrs.Metadata,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// PurgeQuarantine deletes every object in the quarantine that was moved
+// there more than grace ago, returning the purged keys.
+func PurgeQuarantine(ctx context.Context, q *Quarantine, grace time.Duration, opts *DeleteOptions) ([]string, error) {
+	objects, err := ListQuarantine(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return PurgeQuarantineObjects(ctx, q, objects, grace, opts)
+}
+
+// PurgeQuarantineObjects is PurgeQuarantine over objects already listed by
+// ListQuarantine.
+func PurgeQuarantineObjects(ctx context.Context, q *Quarantine, objects []*Object, grace time.Duration, opts *DeleteOptions) ([]string, error) {
+	before := time.Now().Add(-grace)
+	purge := []string{}
+	for _, obj := range objects {
+		if obj.ModTime.Before(before) {
+			purge = append(purge, q.key(obj.Key))
+		}
+	}
+
+	// Quarantined backups already passed the delete limits when moved.
+	purgeOpts := *opts
+	purgeOpts.MaxDeleteCount = 0
+	purgeOpts.Quarantine = nil
+	purgeOpts.Scope = Scope{Prefix: q.key(q.KeyPrefix)}
+	if err := DeletePruneListWithOptions(ctx, q.Bucket, purge, &purgeOpts); err != nil {
+		return purge, fmt.Errorf("purging quarantine: %v", err)
+	}
+	return purge, nil
+}
+
+// ListQuarantine lists every quarantined object under the key prefix by its
+// original key. The modification time of each is when it was moved into the
+// quarantine.
+func ListQuarantine(ctx context.Context, q *Quarantine) ([]*Object, error) {
+	prefix := q.key(q.KeyPrefix)
+	it := q.Bucket.List(&blob.ListOptions{Prefix: prefix})
+	objects := []*Object{}
+	for {
+		obj, err := it.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("listing quarantine: %v", err)
+		}
+		if obj.IsDir || !strings.HasPrefix(obj.Key, prefix) {
+			continue
+		}
+		objects = append(objects, &Object{
+			Key:     strings.TrimPrefix(obj.Key, q.Prefix),
+			MD5:     obj.MD5,
+			ModTime: obj.ModTime,
+			Size:    obj.Size,
+		})
+	}
+	return objects, nil
+}
+
+// RestoreError lists every backup that failed to restore.
+type RestoreError struct {
+	Failures []*KeyFailure
+}
+
+func (e *RestoreError) Error() string {
+	return failuresError("restoring", e.Failures)
+}
+
+// Restore moves quarantined backups back into bucket under their original
+// keys, retrying as set in opts. A backup is never restored over an existing
+// object. Every key is attempted, the restored keys are returned along with
+// a *RestoreError for those that could not be.
+func Restore(ctx context.Context, q *Quarantine, bucket *blob.Bucket, keys []string, opts *DeleteOptions) ([]string, error) {
+	restored := []string{}
+	failed := &RestoreError{}
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return restored, fmt.Errorf("stopping restore before backup %s: %v", key, err)
+		}
+		if err := q.restore(ctx, bucket, key, opts); err != nil {
+			failed.Failures = append(failed.Failures, &KeyFailure{Key: key, Err: err})
+			continue
+		}
+		restored = append(restored, key)
+	}
+	if len(failed.Failures) > 0 {
+		return restored, failed
+	}
+	return restored, nil
+}
+
+func (q *Quarantine) restore(ctx context.Context, bucket *blob.Bucket, key string, opts *DeleteOptions) error {
+	if exists, err := q.Bucket.Exists(ctx, q.key(key)); err != nil {
+		return err
+	} else if !exists {
+		return errors.New("not in quarantine")
+	}
+	if exists, err := bucket.Exists(ctx, key); err != nil {
+		return err
+	} else if exists {
+		return errors.New("refusing to overwrite existing object")
+	}
+
+	err := retry(ctx, opts, func() error {
+		return copyObject(ctx, q.Bucket, q.key(key), bucket, key)
+	})
+	if err != nil {
+		return fmt.Errorf("copying from quarantine: %v", err)
+	}
+	return retry(ctx, opts, func() error {
+		return q.Bucket.Delete(ctx, q.key(key))
+	})
+}
@@ -0,0 +1,179 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+)
+
+func TestQuarantineMove(t *testing.T) {
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+
+	for _, separate := range []bool{false, true} {
+		bucket, err := blob.OpenBucket(context.Background(), "mem://")
+		if err != nil {
+			t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+		}
+		defer bucket.Close()
+		if err := createDummyFiles(bucket, files); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+
+		quarantine := &Quarantine{Bucket: bucket, Prefix: "quarantine/"}
+		if separate {
+			quarantine.Bucket, err = blob.OpenBucket(context.Background(), "mem://")
+			if err != nil {
+				t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+			}
+			defer quarantine.Bucket.Close()
+		}
+		scope := Scope{Exclude: []string{"quarantine/"}}
+
+		res, err := CreatePruneListWithOptions(context.Background(), bucket, WithKeepPerVersion(1), &PruneOptions{
+			Scope: scope,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating prune list: %v", err)
+		}
+		err = DeletePruneListWithOptions(context.Background(), bucket, res.Prune, &DeleteOptions{
+			Quarantine: quarantine,
+			Scope:      scope,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error moving prune list: %v", err)
+		}
+
+		if exists, _ := bucket.Exists(context.Background(), files[0]); exists {
+			t.Errorf("expected %s to be moved out of the bucket", files[0])
+		}
+		data, err := quarantine.Bucket.ReadAll(context.Background(), "quarantine/"+files[0])
+		if err != nil || !bytes.Equal(data, DummyData) {
+			t.Errorf("expected %s to be copied into quarantine: %v", files[0], err)
+		}
+
+		res, err = CreatePruneListWithOptions(context.Background(), bucket, WithKeepPerVersion(1), &PruneOptions{
+			Scope: scope,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating second prune list: %v", err)
+		}
+		if len(res.Backups) != 1 || len(res.NonBackup) != 0 || len(res.Prune) != 0 {
+			t.Errorf("expected quarantined backups to be excluded from listing, got %v", res.Prune)
+		}
+
+		purged, err := PurgeQuarantine(context.Background(), quarantine, time.Hour, &DeleteOptions{})
+		if err != nil || len(purged) != 0 {
+			t.Errorf("expected nothing within the grace period to be purged, purged %v: %v", purged, err)
+		}
+		purged, err = PurgeQuarantine(context.Background(), quarantine, -time.Second, &DeleteOptions{})
+		if err != nil || len(purged) != 1 {
+			t.Errorf("expected quarantined backup to be purged, purged %v: %v", purged, err)
+		}
+		if exists, _ := quarantine.Bucket.Exists(context.Background(), "quarantine/"+files[0]); exists {
+			t.Errorf("expected %s to be purged from quarantine", files[0])
+		}
+		if exists, _ := bucket.Exists(context.Background(), files[1]); !exists {
+			t.Errorf("expected kept backup %s to survive purge", files[1])
+		}
+	}
+}
+
+func TestQuarantineRestore(t *testing.T) {
+	ctx := context.Background()
+	bucket, err := blob.OpenBucket(ctx, "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, file := range files {
+		err := bucket.WriteAll(ctx, file, DummyData, &blob.WriterOptions{
+			Metadata: map[string]string{"instance": "prod"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+	}
+
+	quarantine := &Quarantine{Bucket: bucket, Prefix: "quarantine/"}
+	if err := DeletePruneListWithOptions(ctx, bucket, files, &DeleteOptions{Quarantine: quarantine}); err != nil {
+		t.Fatalf("unexpected error moving backups to quarantine: %v", err)
+	}
+	if err := bucket.WriteAll(ctx, files[1], []byte("new backup"), nil); err != nil {
+		t.Fatalf("unexpected error writing replacement backup: %v", err)
+	}
+
+	restored, err := Restore(ctx, quarantine, bucket, append(files, "missing_gitlab_backup.tar"), &DeleteOptions{})
+	restoreErr, ok := err.(*RestoreError)
+	if !ok {
+		t.Fatalf("expected restore error, got %v", err)
+	}
+	if len(restoreErr.Failures) != 2 {
+		t.Errorf("expected existing and missing backups to fail restoring, got %v", restoreErr)
+	}
+	if len(restored) != 1 || restored[0] != files[0] {
+		t.Fatalf("expected only %s to be restored, got %v", files[0], restored)
+	}
+
+	attrs, err := bucket.Attributes(ctx, files[0])
+	if err != nil {
+		t.Fatalf("unexpected error getting restored backup attributes: %v", err)
+	}
+	if attrs.Metadata["instance"] != "prod" {
+		t.Errorf("expected restored backup to keep its metadata, got %v", attrs.Metadata)
+	}
+	if exists, _ := bucket.Exists(ctx, "quarantine/"+files[0]); exists {
+		t.Error("expected restored backup to be removed from quarantine")
+	}
+	data, err := bucket.ReadAll(ctx, files[1])
+	if err != nil || string(data) != "new backup" {
+		t.Errorf("expected existing backup %s to not be overwritten", files[1])
+	}
+	if exists, _ := bucket.Exists(ctx, "quarantine/"+files[1]); !exists {
+		t.Error("expected backup refused for restore to remain in quarantine")
+	}
+}
+
+func TestPurgeQuarantineKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	bucket, err := blob.OpenBucket(ctx, "mem://")
+	if err != nil {
+		t.Fatalf("unexpected error opening memory bucket for test: %v", err)
+	}
+	defer bucket.Close()
+
+	files := []string{
+		"quarantine/gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"quarantine/gitlab-staging/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+	}
+	if err := createDummyFiles(bucket, files); err != nil {
+		t.Fatalf("unexpected error seeding bucket with files: %v", err)
+	}
+
+	quarantine := &Quarantine{Bucket: bucket, Prefix: "quarantine/", KeyPrefix: "gitlab-prod/"}
+	objects, err := ListQuarantine(ctx, quarantine)
+	if err != nil {
+		t.Fatalf("unexpected error listing quarantine: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar" {
+		t.Errorf("expected only the backup under the key prefix to be listed, got %v", objects)
+	}
+
+	purged, err := PurgeQuarantine(ctx, quarantine, -time.Second, &DeleteOptions{})
+	if err != nil || len(purged) != 1 || purged[0] != files[0] {
+		t.Errorf("expected only %s to be purged, purged %v: %v", files[0], purged, err)
+	}
+	if exists, _ := bucket.Exists(ctx, files[1]); !exists {
+		t.Errorf("expected %s outside of the key prefix to survive purge", files[1])
+	}
+}
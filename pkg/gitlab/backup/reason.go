@@ -0,0 +1,45 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reason explains a single keep or prune decision. Deciders built from other
+// deciders attach the reasons of the deciders they consulted as children.
+type Reason struct {
+	Keep     bool
+	Message  string
+	Children []*Reason
+}
+
+func newReason(keep bool, format string, a ...interface{}) *Reason {
+	return &Reason{
+		Keep:    keep,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+func (r *Reason) Verdict() string {
+	if r.Keep {
+		return "kept"
+	}
+	return "pruned"
+}
+
+func (r *Reason) String() string {
+	builder := strings.Builder{}
+	r.write(&builder, 0)
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func (r *Reason) write(builder *strings.Builder, depth int) {
+	builder.WriteString(strings.Repeat("  ", depth))
+	builder.WriteString(r.Verdict())
+	builder.WriteString(": ")
+	builder.WriteString(r.Message)
+	builder.WriteString("\n")
+	for _, child := range r.Children {
+		child.write(builder, depth+1)
+	}
+}
@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"fmt"
+)
+
+// Safety holds safeguards applied after a decider has run. A zero value
+// disables the corresponding safeguard.
+type Safety struct {
+	// MinKeep is the number of newest backups that are always kept.
+	MinKeep int
+	// MaxDeleteCount is the largest number of objects a single run may
+	// delete.
+	MaxDeleteCount int
+	// MaxDeletePercent is the largest percentage of parsed backups a single
+	// run may delete.
+	MaxDeletePercent float64
+}
+
+// DeleteLimitError is returned when a prune list exceeds a safety limit.
+type DeleteLimitError struct {
+	Limit string
+	Value string
+}
+
+func (e *DeleteLimitError) Error() string {
+	return fmt.Sprintf("refusing to delete, %s exceeds %s", e.Value, e.Limit)
+}
+
+func (s Safety) applyMinKeep(backups BackupList, verdicts map[*Backup]Verdict) {
+	for i, b := range backups {
+		if i >= s.MinKeep {
+			return
+		}
+		verdict := verdicts[b]
+		if verdict.Keep {
+			continue
+		}
+		r := newReason(true, "minKeep safety floor keeps the newest %d backups", s.MinKeep)
+		r.Children = []*Reason{verdictReason(verdict)}
+		verdicts[b] = Verdict{Keep: true, Reason: r}
+	}
+}
+
+func (s Safety) checkLimits(result *PruneResult) error {
+	if err := s.checkCount(len(result.Prune)); err != nil {
+		return err
+	}
+	if s.MaxDeletePercent <= 0 || len(result.Backups) == 0 {
+		return nil
+	}
+
+	pruned := 0
+	for _, b := range result.Backups {
+		if !result.Verdicts[b].Keep {
+			pruned++
+		}
+	}
+	percent := float64(pruned) / float64(len(result.Backups)) * 100
+	if percent > s.MaxDeletePercent {
+		return &DeleteLimitError{
+			Limit: fmt.Sprintf("maxDeletePercent %g%%", s.MaxDeletePercent),
+			Value: fmt.Sprintf("pruning %d of %d backups (%.1f%%)", pruned, len(result.Backups), percent),
+		}
+	}
+	return nil
+}
+
+func (s Safety) checkCount(count int) error {
+	if s.MaxDeleteCount > 0 && count > s.MaxDeleteCount {
+		return &DeleteLimitError{
+			Limit: fmt.Sprintf("maxDeleteCount %d", s.MaxDeleteCount),
+			Value: fmt.Sprintf("deleting %d objects", count),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope limits listing and deletion to the part of a bucket under Prefix,
+// leaving out anything under an Exclude prefix. When Delimiter is set only
+// objects directly under the prefix are listed, otherwise every object under
+// the prefix is.
+type Scope struct {
+	Prefix    string
+	Delimiter string
+	Exclude   []string
+}
+
+func (s Scope) contains(key string) bool {
+	if !strings.HasPrefix(key, s.Prefix) {
+		return false
+	}
+	for _, exclude := range s.Exclude {
+		if strings.HasPrefix(key, exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+// name is the key relative to the scope prefix as shown to key parsers.
+func (s Scope) name(key string) string {
+	return strings.TrimPrefix(key, s.Prefix)
+}
+
+// checkKeys refuses the whole list when any key is outside of the scope so
+// a bad prune list cannot delete objects belonging to something else.
+func (s Scope) checkKeys(keys []string) error {
+	for _, key := range keys {
+		if !strings.HasPrefix(key, s.Prefix) {
+			return fmt.Errorf("refusing to delete %s outside of prefix %s", key, s.Prefix)
+		} else if !s.contains(key) {
+			return fmt.Errorf("refusing to delete excluded %s", key)
+		}
+	}
+	return nil
+}
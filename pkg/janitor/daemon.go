@@ -0,0 +1,169 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+	"github.com/tlmiller/gitlab-janitor/pkg/metrics"
+)
+
+// Status describes the most recent janitor run made by a Daemon.
+type Status struct {
+	Running    bool
+	Runs       int
+	Skipped    int
+	LastStart  time.Time
+	LastEnd    time.Time
+	LastError  error
+	LastPruned int
+	NextRun    time.Time
+}
+
+type Daemon struct {
+	conf     *config.Config
+	metrics  map[string]*metrics.Metrics
+	handler  http.Handler
+	schedule cron.Schedule
+	jitter   time.Duration
+	random   *rand.Rand
+
+	lock   sync.Mutex
+	status Status
+	wg     sync.WaitGroup
+}
+
+// NewDaemon creates a daemon running every job in the config on each
+// scheduled run.
+func NewDaemon(conf *config.Config) (*Daemon, error) {
+	schedule, jitter, err := config.ToSchedule(conf.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	jobs, _, err := config.ToJobConfigs(conf, "")
+	if err != nil {
+		return nil, err
+	}
+	ms := metrics.NewJobs(jobs)
+	return &Daemon{
+		conf:     conf,
+		metrics:  ms,
+		handler:  ms[jobs[0]].Handler(),
+		schedule: schedule,
+		jitter:   jitter,
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Metrics returns the metrics for each job, keyed by job name.
+func (d *Daemon) Metrics() map[string]*metrics.Metrics {
+	return d.metrics
+}
+
+func (d *Daemon) Status() Status {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.status
+}
+
+// Serve runs the janitor on the configured schedule until the context is
+// done. A run that is still in progress when the context is done is asked to
+// stop before its next deletion and waited on before Serve returns. Metrics
+// are served over HTTP when a listen address is configured.
+func (d *Daemon) Serve(ctx context.Context) error {
+	defer d.wg.Wait()
+	if d.conf.Metrics.Listen != "" {
+		srv, err := d.serveMetrics()
+		if err != nil {
+			return err
+		}
+		defer srv.Close()
+	}
+
+	for {
+		next := d.next(time.Now())
+		d.lock.Lock()
+		d.status.NextRun = next
+		d.lock.Unlock()
+		logging.FromContext(ctx).Infof("next janitor run at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			d.trigger(ctx)
+		}
+	}
+}
+
+func (d *Daemon) serveMetrics() (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.Handle(d.conf.Metrics.Path, d.handler)
+	listener, err := net.Listen("tcp", d.conf.Metrics.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("listening for metrics: %v", err)
+	}
+
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.Default().Errorf("serving metrics: %v", err)
+		}
+	}()
+	logging.Default().Infof("serving metrics on %s%s", listener.Addr(), d.conf.Metrics.Path)
+	return srv, nil
+}
+
+func (d *Daemon) next(now time.Time) time.Time {
+	next := d.schedule.Next(now)
+	if d.jitter > 0 {
+		next = next.Add(time.Duration(d.random.Int63n(int64(d.jitter))))
+	}
+	return next
+}
+
+func (d *Daemon) trigger(ctx context.Context) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.status.Running {
+		d.status.Skipped++
+		logging.FromContext(ctx).Warnf("skipping janitor run, previous run started at %s is still running",
+			d.status.LastStart.Format(time.RFC3339))
+		return
+	}
+
+	d.status.Running = true
+	d.status.LastStart = time.Now()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ctx, _ := withRun(ctx)
+		results, err := RunJobs(ctx, d.conf, "", d.metrics)
+
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		d.status.Running = false
+		d.status.Runs++
+		d.status.LastEnd = time.Now()
+		d.status.LastError = err
+		d.status.LastPruned = 0
+		for _, result := range results {
+			d.status.LastPruned += len(result.Prune)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Errorf("janitor run failed: %v", err)
+		} else {
+			logging.FromContext(ctx).Infof("janitor run finished in %s", d.status.LastEnd.Sub(d.status.LastStart))
+		}
+	}()
+}
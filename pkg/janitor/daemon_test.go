@@ -0,0 +1,66 @@
+package janitor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+)
+
+func TestDaemonRunsOnSchedule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("dummy data"), 0600); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+	}
+
+	conf := config.New()
+	conf.Bucket.URL = "file://" + dir
+	conf.Decider = &config.Decider{
+		Type:    "keepPerVersion",
+		Options: map[string]interface{}{"count": 1},
+	}
+	conf.Schedule = &config.Schedule{Cron: "@every 1s"}
+
+	daemon, err := NewDaemon(conf)
+	if err != nil {
+		t.Fatalf("unexpected error creating daemon: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+	if err := daemon.Serve(ctx); err != nil {
+		t.Fatalf("unexpected error serving daemon: %v", err)
+	}
+
+	status := daemon.Status()
+	if status.Runs < 1 {
+		t.Fatalf("expected daemon to have run at least once, ran %d times", status.Runs)
+	}
+	if status.Running {
+		t.Error("expected daemon to have no run in progress after serve returned")
+	}
+	if status.LastError != nil {
+		t.Errorf("unexpected error from last daemon run: %v", status.LastError)
+	}
+	if _, err := os.Stat(filepath.Join(dir, files[0])); !os.IsNotExist(err) {
+		t.Errorf("expected backup %s to be pruned", files[0])
+	}
+	if _, err := os.Stat(filepath.Join(dir, files[1])); err != nil {
+		t.Errorf("expected backup %s to be kept: %v", files[1], err)
+	}
+}
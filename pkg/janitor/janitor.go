@@ -0,0 +1,290 @@
+package janitor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+	"github.com/tlmiller/gitlab-janitor/pkg/metrics"
+)
+
+// Plan lists the configured bucket and decides which backups to prune.
+// Deciders are built from the config on every call so time based deciders
+// are relative to the time of the call. The result is returned along with a
+// *backup.DeleteLimitError when the plan exceeds a safety limit.
+func Plan(ctx context.Context, conf *config.Config) (*backup.PruneResult, error) {
+	ctx, _ = withRun(ctx)
+	bucket, err := config.ToBucketWithContext(ctx, conf.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup bucket: %v", err)
+	}
+	defer bucket.Close()
+
+	return plan(ctx, bucket, conf)
+}
+
+// Run plans and then deletes the prune list unless the config is in dry run
+// mode. The whole run is stopped once the configured timeout passes. The run
+// is recorded in m, which may be nil, and every removal in the audit log.
+func Run(ctx context.Context, conf *config.Config, m *metrics.Metrics) (result *backup.PruneResult, err error) {
+	start := time.Now()
+	defer func() {
+		m.ObserveRun(start, err)
+	}()
+	ctx, runID := withRun(ctx)
+	logger := logging.FromContext(ctx)
+
+	timeout, err := config.ToTimeout(conf)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	deleteOpts, err := config.ToDeleteOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeAfter, err := config.ToPurgeAfter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := config.ToBucketWithContext(ctx, conf.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup bucket: %v", err)
+	}
+	defer bucket.Close()
+
+	var quarantine *backup.Quarantine
+	if config.QuarantineEnabled(conf) {
+		quarantine, err = openQuarantine(ctx, conf, bucket)
+		if err != nil {
+			return nil, err
+		}
+		defer closeQuarantine(quarantine, bucket)
+	}
+	action := audit.ActionDelete
+	if conf.Delete.Action == config.DeleteActionMove {
+		action = audit.ActionMove
+		deleteOpts.Quarantine = quarantine
+	}
+
+	auditLog, err := config.ToAuditLog(ctx, conf, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := auditLog.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	deleteOpts.Deleted = observeDelete(m, auditLog, action)
+
+	result, err = plan(ctx, bucket, conf)
+	if result != nil {
+		m.ObservePruneResult(result)
+	}
+	if err != nil {
+		return result, err
+	}
+	auditLog.Prepare(result)
+
+	if conf.DryRun {
+		logger.Infof("dry run, skipping deletion of %d backups", len(result.Prune))
+		return result, nil
+	}
+
+	if deleteOpts.Quarantine != nil {
+		logger.Infof("moving %d backups to quarantine", len(result.Prune))
+	} else {
+		logger.Infof("deleting %d backups", len(result.Prune))
+	}
+	err = backup.DeletePruneListWithOptions(ctx, bucket, result.Prune, deleteOpts)
+	if err != nil {
+		return result, fmt.Errorf("deleting backups: %v", err)
+	}
+
+	if purgeAfter > 0 {
+		purgeOpts := *deleteOpts
+		purgeOpts.Deleted = observeDelete(m, auditLog, audit.ActionPurge)
+		objects, err := backup.ListQuarantine(ctx, quarantine)
+		if err != nil {
+			return result, err
+		}
+		m.ObserveQuarantine(quarantine.Prefix, objects)
+		purged, err := backup.PurgeQuarantineObjects(ctx, quarantine, objects, purgeAfter, &purgeOpts)
+		logger.Infof("purged %d quarantined backups older than %s", len(purged), purgeAfter)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// observeDelete records each removal in the metrics and audit log.
+func observeDelete(m *metrics.Metrics, auditLog *audit.Log, action string) func(string, error) {
+	return func(key string, err error) {
+		m.ObserveDelete(action, key, err)
+		auditLog.Record(action, key, err)
+	}
+}
+
+type runIDKey struct{}
+
+// withRun returns a context carrying a new run ID and a logger adding it to
+// every line, unless ctx is already part of a run.
+func withRun(ctx context.Context) (context.Context, string) {
+	if runID, ok := ctx.Value(runIDKey{}).(string); ok {
+		return ctx, runID
+	}
+	runID := NewRunID()
+	return WithRunID(ctx, runID), runID
+}
+
+// WithRunID returns a context making everything run with it part of the run
+// runID, for callers that log before the run starts.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	ctx = context.WithValue(ctx, runIDKey{}, runID)
+	return logging.NewContext(ctx, logging.FromContext(ctx).With(logging.FieldRunID, runID))
+}
+
+// NewRunID returns a random identifier for a run, used to tie together
+// everything the run records.
+func NewRunID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(id)
+}
+
+// openQuarantine returns the configured quarantine, opening its bucket when
+// it is not the bucket being cleaned.
+func openQuarantine(ctx context.Context, conf *config.Config, bucket *blob.Bucket) (*backup.Quarantine, error) {
+	quarantine := &backup.Quarantine{
+		Bucket:    bucket,
+		Prefix:    config.ToQuarantinePrefix(conf),
+		KeyPrefix: config.ToPrefix(conf.Bucket),
+	}
+	if !config.QuarantineInBucket(conf) {
+		qBucket, err := config.ToBucketWithContext(ctx, &config.Bucket{URL: conf.Quarantine.URL})
+		if err != nil {
+			return nil, fmt.Errorf("getting quarantine bucket: %v", err)
+		}
+		quarantine.Bucket = qBucket
+	}
+	return quarantine, nil
+}
+
+func closeQuarantine(quarantine *backup.Quarantine, bucket *blob.Bucket) {
+	if quarantine.Bucket != bucket {
+		quarantine.Bucket.Close()
+	}
+}
+
+// Restore moves quarantined backups back into the configured bucket from the
+// job's quarantine. When no keys are given every quarantined backup within
+// the bucket prefix that was quarantined after since is restored.
+func Restore(ctx context.Context, conf *config.Config, keys []string, since time.Time) ([]string, error) {
+	if _, err := config.ToPurgeAfter(conf); err != nil {
+		return nil, err
+	}
+	opts, err := config.ToDeleteOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := config.ToBucketWithContext(ctx, conf.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup bucket: %v", err)
+	}
+	defer bucket.Close()
+
+	quarantine, err := openQuarantine(ctx, conf, bucket)
+	if err != nil {
+		return nil, err
+	}
+	defer closeQuarantine(quarantine, bucket)
+
+	prefix := config.ToPrefix(conf.Bucket)
+	if len(keys) == 0 {
+		objects, err := backup.ListQuarantine(ctx, quarantine)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objects {
+			if !obj.ModTime.Before(since) {
+				keys = append(keys, obj.Key)
+			}
+		}
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			return nil, fmt.Errorf("refusing to restore %s outside of prefix %s", key, prefix)
+		}
+	}
+	return backup.Restore(ctx, quarantine, bucket, keys, opts)
+}
+
+func plan(ctx context.Context, bucket *blob.Bucket, conf *config.Config) (*backup.PruneResult, error) {
+	logging.FromContext(ctx).Debugf("building decider %s with options %v", conf.Decider.Type, conf.Decider.Options)
+	decider, err := config.ToDecider(conf.Decider)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup decider: %v", err)
+	}
+
+	opts, err := config.ToPruneOptions(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := backup.CreatePruneListWithOptions(ctx, bucket, decider, opts)
+	if _, limited := err.(*backup.DeleteLimitError); limited {
+		return result, err
+	} else if err != nil {
+		return nil, fmt.Errorf("generating backup prune list: %v", err)
+	}
+	return result, nil
+}
+
+// Check lists the configured bucket and checks the backups are still being
+// produced, returning any problems found.
+func Check(ctx context.Context, conf *config.Config) ([]backup.CheckProblem, error) {
+	ctx, _ = withRun(ctx)
+	opts, err := config.ToCheckOptions(conf.Check)
+	if err != nil {
+		return nil, fmt.Errorf("getting check options: %v", err)
+	}
+
+	parser, err := config.ToKeyParser(conf.Parser)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup key parser: %v", err)
+	}
+
+	bucket, err := config.ToBucketWithContext(ctx, conf.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("getting backup bucket: %v", err)
+	}
+	defer bucket.Close()
+
+	listing, err := backup.ListBackupsWithScope(ctx, bucket, parser, config.ToJobScope(conf))
+	if err != nil {
+		return nil, err
+	}
+	return backup.Check(listing.Backups, opts, time.Now()), nil
+}
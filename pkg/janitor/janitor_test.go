@@ -0,0 +1,132 @@
+package janitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+)
+
+func TestRunWritesAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bucketDir := filepath.Join(dir, "bucket")
+	if err := os.Mkdir(bucketDir, 0700); err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(bucketDir, file), []byte("dummy data"), 0600); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+	}
+
+	auditFile := filepath.Join(dir, "audit.jsonl")
+	conf := config.New()
+	conf.Bucket.URL = "file://" + bucketDir
+	conf.Audit.File = auditFile
+	conf.Decider = &config.Decider{
+		Type:    "keepPerVersion",
+		Options: map[string]interface{}{"count": 1},
+	}
+
+	logs := &bytes.Buffer{}
+	ctx := logging.NewContext(context.Background(), logging.New(logs, logging.LevelDebug, logging.FormatText))
+	if _, err := Run(ctx, conf, nil); err != nil {
+		t.Fatalf("unexpected error running janitor: %v", err)
+	}
+
+	f, err := os.Open(auditFile)
+	if err != nil {
+		t.Fatalf("unexpected error opening audit log: %v", err)
+	}
+	defer f.Close()
+
+	records := []audit.Record{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		r := audit.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("unexpected error decoding audit log: %v", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(records))
+	}
+	r := records[0]
+	if r.Key != files[0] || r.Action != audit.ActionDelete || r.Version != "12.0.3" || r.Edition != "ee" {
+		t.Errorf("unexpected audit record %+v", r)
+	}
+	if r.RunID == "" || r.Reason == "" || r.Size != 10 {
+		t.Errorf("expected audit record with run id, reason and size, got %+v", r)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	for _, line := range lines {
+		if !strings.HasSuffix(line, logging.FieldRunID+"="+r.RunID) {
+			t.Errorf("expected log line with run id %s, got %s", r.RunID, line)
+		}
+	}
+	if !strings.Contains(logs.String(), "level=debug msg=\"removed backup "+files[0]+"\"") {
+		t.Errorf("expected debug log of the removed backup, got %s", logs.String())
+	}
+}
+
+func TestRunStaysWithinPrefix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, prefix := range []string{"prod", "prod-old"} {
+		if err := os.Mkdir(filepath.Join(dir, prefix), 0700); err != nil {
+			t.Fatalf("unexpected error creating prefix directory: %v", err)
+		}
+		for _, file := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, prefix, file), []byte("dummy data"), 0600); err != nil {
+				t.Fatalf("unexpected error seeding bucket with files: %v", err)
+			}
+		}
+	}
+
+	conf := config.New()
+	conf.Bucket = &config.Bucket{URL: "file://" + dir, Prefix: "prod"}
+	conf.Decider = &config.Decider{
+		Type:    "keepPerVersion",
+		Options: map[string]interface{}{"count": 1},
+	}
+	result, err := Run(context.Background(), conf, nil)
+	if err != nil {
+		t.Fatalf("unexpected error running janitor: %v", err)
+	}
+	if len(result.Backups) != 2 || len(result.Prune) != 1 || result.Prune[0] != "prod/"+files[0] {
+		t.Errorf("expected only prod/%s to be pruned, got %v", files[0], result.Prune)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, "prod-old", file)); err != nil {
+			t.Errorf("expected prod-old/%s outside of the prefix to survive: %v", file, err)
+		}
+	}
+}
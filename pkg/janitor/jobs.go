@@ -0,0 +1,102 @@
+package janitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+	"github.com/tlmiller/gitlab-janitor/pkg/logging"
+	"github.com/tlmiller/gitlab-janitor/pkg/metrics"
+)
+
+type JobError struct {
+	Job string
+	Err error
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job %s: %v", e.Job, e.Err)
+}
+
+// JobsError is returned when one or more jobs fail, the remaining jobs will
+// still have been run.
+type JobsError struct {
+	Errors []*JobError
+	Jobs   int
+}
+
+func (e *JobsError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d/%d jobs failed: %s", len(e.Errors), e.Jobs, strings.Join(msgs, "; "))
+}
+
+// RunJobs runs the named job, or every job in the config when name is
+// empty. Jobs are run one after another in config order unless the config
+// asks for them to be run in parallel. A failing job does not stop the
+// others. Metrics for each job are looked up by name in ms, which may be nil.
+func RunJobs(ctx context.Context, conf *config.Config, name string, ms map[string]*metrics.Metrics) (map[string]*backup.PruneResult, error) {
+	names, confs, err := config.ToJobConfigs(conf, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, _ = withRun(ctx)
+	logger := logging.FromContext(ctx)
+	var (
+		lock    sync.Mutex
+		results = map[string]*backup.PruneResult{}
+		errs    = map[string]error{}
+		wg      sync.WaitGroup
+	)
+	runJob := func(job string) {
+		jobCtx := ctx
+		if job != "" {
+			jobCtx = logging.NewContext(ctx, logger.With(logging.FieldJob, job))
+			logging.FromContext(jobCtx).Infof("running job %s", job)
+		}
+		result, err := Run(jobCtx, confs[job], ms[job])
+
+		lock.Lock()
+		defer lock.Unlock()
+		if result != nil {
+			results[job] = result
+		}
+		if err != nil {
+			errs[job] = err
+		}
+	}
+
+	for _, job := range names {
+		if !conf.Parallel {
+			runJob(job)
+			continue
+		}
+		wg.Add(1)
+		go func(job string) {
+			defer wg.Done()
+			runJob(job)
+		}(job)
+	}
+	wg.Wait()
+
+	if len(names) == 1 && names[0] == "" {
+		return results, errs[""]
+	}
+	jobsErr := &JobsError{Jobs: len(names)}
+	for _, job := range names {
+		if err, failed := errs[job]; failed {
+			logger.With(logging.FieldJob, job).Errorf("job %s failed: %v", job, err)
+			jobsErr.Errors = append(jobsErr.Errors, &JobError{Job: job, Err: err})
+		}
+	}
+	if len(jobsErr.Errors) > 0 {
+		return results, jobsErr
+	}
+	return results, nil
+}
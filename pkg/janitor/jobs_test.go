@@ -0,0 +1,59 @@
+package janitor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+)
+
+func TestRunJobsIsolatesFailures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, file := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("dummy data"), 0600); err != nil {
+			t.Fatalf("unexpected error seeding bucket with files: %v", err)
+		}
+	}
+
+	for _, parallel := range []bool{false, true} {
+		conf := config.New()
+		conf.Parallel = parallel
+		conf.DryRun = true
+		conf.Decider = &config.Decider{
+			Type:    "keepPerVersion",
+			Options: map[string]interface{}{"count": 1},
+		}
+		conf.Jobs = []*config.Job{
+			{Name: "broken", Bucket: &config.Bucket{}},
+			{Name: "prod", Bucket: &config.Bucket{URL: "file://" + dir}},
+		}
+
+		results, err := RunJobs(context.Background(), conf, "", nil)
+		jobsErr, ok := err.(*JobsError)
+		if !ok {
+			t.Fatalf("expected jobs error, got %v", err)
+		}
+		if len(jobsErr.Errors) != 1 || jobsErr.Errors[0].Job != "broken" {
+			t.Errorf("expected only the broken job to fail, got %v", jobsErr)
+		}
+		if result := results["prod"]; result == nil || len(result.Prune) != 1 {
+			t.Errorf("expected prod job to run despite broken job failing")
+		}
+
+		if _, err := RunJobs(context.Background(), conf, "prod", nil); err != nil {
+			t.Errorf("unexpected error running only the prod job: %v", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package janitor
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/config"
+)
+
+func TestRestoreSharedQuarantine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Both jobs hold the same backup keys so share nothing but the
+	// quarantine.
+	files := []string{
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+	}
+	for _, job := range []string{"a", "b", "q"} {
+		if err := os.Mkdir(filepath.Join(dir, job), 0700); err != nil {
+			t.Fatalf("unexpected error creating bucket directory: %v", err)
+		}
+	}
+	for _, job := range []string{"a", "b"} {
+		for _, file := range files {
+			if err := ioutil.WriteFile(filepath.Join(dir, job, file), []byte(job), 0600); err != nil {
+				t.Fatalf("unexpected error seeding bucket with files: %v", err)
+			}
+		}
+	}
+
+	conf := config.New()
+	conf.Decider = &config.Decider{
+		Type:    "keepPerVersion",
+		Options: map[string]interface{}{"count": 1},
+	}
+	conf.Delete.Action = config.DeleteActionMove
+	conf.Quarantine.URL = "file://" + filepath.Join(dir, "q")
+	conf.Jobs = []*config.Job{
+		{Name: "a", Bucket: &config.Bucket{URL: "file://" + filepath.Join(dir, "a")}},
+		{Name: "b", Bucket: &config.Bucket{URL: "file://" + filepath.Join(dir, "b")}},
+	}
+	if _, err := RunJobs(context.Background(), conf, "", nil); err != nil {
+		t.Fatalf("unexpected error running jobs: %v", err)
+	}
+	for _, job := range []string{"a", "b"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "q", "quarantine", job, files[0]))
+		if err != nil || string(data) != job {
+			t.Errorf("expected job %s backup in its own quarantine namespace: %v", job, err)
+		}
+	}
+
+	jobConf, err := config.ToJobConfig(conf, "a")
+	if err != nil {
+		t.Fatalf("unexpected error getting job config: %v", err)
+	}
+	restored, err := Restore(context.Background(), jobConf, nil, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error restoring job a: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != files[0] {
+		t.Errorf("expected only job a backup %s to be restored, got %v", files[0], restored)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "a", files[0])); err != nil || string(data) != "a" {
+		t.Errorf("expected job a backup to be restored into its bucket: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "q", "quarantine", "b", files[0])); err != nil {
+		t.Errorf("expected job b backup to remain quarantined: %v", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "janitor")
+	if err != nil {
+		t.Fatalf("unexpected error creating bucket directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := "gitlab-prod/1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar"
+	recent := "gitlab-prod/1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar"
+	staging := "gitlab-staging/1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar"
+	for _, key := range []string{old, recent, staging} {
+		path := filepath.Join(dir, "quarantine", filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatalf("unexpected error creating quarantine directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte("dummy data"), 0600); err != nil {
+			t.Fatalf("unexpected error seeding quarantine with files: %v", err)
+		}
+	}
+	quarantined := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "quarantine", filepath.FromSlash(old)), quarantined, quarantined); err != nil {
+		t.Fatalf("unexpected error ageing quarantined backup: %v", err)
+	}
+
+	conf := config.New()
+	conf.Bucket = &config.Bucket{URL: "file://" + dir, Prefix: "gitlab-prod/"}
+	conf.Delete.Action = config.DeleteActionMove
+
+	// Each test restores from what the previous tests left in quarantine.
+	tests := []struct {
+		Keys       []string
+		Restored   []string
+		ShouldFail bool
+	}{
+		{
+			Keys:       []string{staging},
+			ShouldFail: true,
+		},
+		{
+			Restored: []string{recent},
+		},
+		{
+			Restored: []string{},
+		},
+		{
+			Keys:     []string{old},
+			Restored: []string{old},
+		},
+	}
+
+	for i, test := range tests {
+		restored, err := Restore(context.Background(), conf, test.Keys, time.Now().Add(-time.Hour))
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if test.ShouldFail {
+			continue
+		}
+		if len(restored) != len(test.Restored) {
+			t.Fatalf("test %d expected %v to be restored, got %v", i, test.Restored, restored)
+		}
+		for j, key := range test.Restored {
+			if restored[j] != key {
+				t.Errorf("test %d expected %v to be restored, got %v", i, test.Restored, restored)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "quarantine", filepath.FromSlash(staging))); err != nil {
+		t.Errorf("expected backup outside of the prefix to remain quarantined: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	FieldJob   = "job"
+	FieldRunID = "run_id"
+)
+
+var (
+	levelNames = map[Level]string{
+		LevelDebug: "debug",
+		LevelInfo:  "info",
+		LevelWarn:  "warn",
+		LevelError: "error",
+	}
+
+	defaultLock   sync.RWMutex
+	defaultLogger = New(os.Stderr, LevelInfo, FormatText)
+)
+
+func (l Level) String() string {
+	if name, exists := levelNames[l]; exists {
+		return name
+	}
+	return strconv.Itoa(int(l))
+}
+
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %s", name)
+}
+
+// ValidFormat reports whether format is a known output format.
+func ValidFormat(format string) bool {
+	return format == FormatJSON || format == FormatText
+}
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+type output struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// Logger writes leveled log lines as text or JSON. Fields added with With
+// are included on every line after the message.
+type Logger struct {
+	out    *output
+	level  Level
+	format string
+	fields []field
+	now    func() time.Time
+}
+
+// New creates a logger writing lines at or above level to w. An unknown
+// format is written as text.
+func New(w io.Writer, level Level, format string) *Logger {
+	return &Logger{
+		out:    &output{w: w},
+		level:  level,
+		format: format,
+		now:    time.Now,
+	}
+}
+
+// Default returns the logger used when none has been set on a context.
+func Default() *Logger {
+	defaultLock.RLock()
+	defer defaultLock.RUnlock()
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultLogger = l
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger set on ctx, or the default logger.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
+	}
+	return Default()
+}
+
+// With returns a logger adding key and value to every line, replacing any
+// existing value for key.
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]field, 0, len(l.fields)+1)
+	for _, f := range l.fields {
+		if f.key != key {
+			fields = append(fields, f)
+		}
+	}
+	child := *l
+	child.fields = append(fields, field{key: key, value: value})
+	return &child
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	l.logf(LevelDebug, format, a...)
+}
+
+func (l *Logger) Infof(format string, a ...interface{}) {
+	l.logf(LevelInfo, format, a...)
+}
+
+func (l *Logger) Warnf(format string, a ...interface{}) {
+	l.logf(LevelWarn, format, a...)
+}
+
+func (l *Logger) Errorf(format string, a ...interface{}) {
+	l.logf(LevelError, format, a...)
+}
+
+func (l *Logger) logf(level Level, format string, a ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	var line []byte
+	t := l.now().UTC().Format(time.RFC3339)
+	msg := fmt.Sprintf(format, a...)
+	if l.format == FormatJSON {
+		line = l.jsonLine(t, level, msg)
+	} else {
+		line = l.textLine(t, level, msg)
+	}
+
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+	l.out.w.Write(line)
+}
+
+func (l *Logger) textLine(t string, level Level, msg string) []byte {
+	builder := strings.Builder{}
+	builder.WriteString("time=" + t)
+	builder.WriteString(" level=" + level.String())
+	builder.WriteString(" msg=" + textValue(msg))
+	for _, f := range l.fields {
+		builder.WriteString(" " + f.key + "=" + textValue(fmt.Sprint(f.value)))
+	}
+	builder.WriteString("\n")
+	return []byte(builder.String())
+}
+
+// textValue quotes values that would otherwise be ambiguous in a line of
+// key=value pairs.
+func textValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func (l *Logger) jsonLine(t string, level Level, msg string) []byte {
+	// Built by hand rather than from a map to keep fields in a stable order.
+	builder := strings.Builder{}
+	builder.WriteString(`{"time":` + jsonValue(t))
+	builder.WriteString(`,"level":` + jsonValue(level.String()))
+	builder.WriteString(`,"msg":` + jsonValue(msg))
+	for _, f := range l.fields {
+		builder.WriteString("," + jsonValue(f.key) + ":" + jsonValue(f.value))
+	}
+	builder.WriteString("}\n")
+	return []byte(builder.String())
+}
+
+func jsonValue(value interface{}) string {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		raw, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return string(raw)
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	tests := []struct {
+		Format string
+		Level  Level
+		Log    func(l *Logger)
+		Expect string
+	}{
+		{
+			Format: FormatText,
+			Level:  LevelInfo,
+			Log: func(l *Logger) {
+				l.Debugf("hidden")
+				l.With(FieldRunID, "abc123").Infof("deleting %d backups", 2)
+			},
+			Expect: "time=2019-08-05T12:00:00Z level=info msg=\"deleting 2 backups\" run_id=abc123\n",
+		},
+		{
+			Format: FormatText,
+			Level:  LevelDebug,
+			Log: func(l *Logger) {
+				l.With(FieldJob, "old").With(FieldJob, "prod").Debugf("skipping")
+			},
+			Expect: "time=2019-08-05T12:00:00Z level=debug msg=skipping job=prod\n",
+		},
+		{
+			Format: FormatJSON,
+			Level:  LevelWarn,
+			Log: func(l *Logger) {
+				l.Infof("hidden")
+				l.With("err", errors.New("access denied")).Errorf("job %s failed", "prod")
+			},
+			Expect: `{"time":"2019-08-05T12:00:00Z","level":"error","msg":"job prod failed","err":"access denied"}` + "\n",
+		},
+	}
+
+	now := time.Date(2019, 8, 5, 12, 0, 0, 0, time.UTC)
+	for i, test := range tests {
+		buf := &bytes.Buffer{}
+		l := New(buf, test.Level, test.Format)
+		l.now = func() time.Time { return now }
+		test.Log(l)
+		if buf.String() != test.Expect {
+			t.Errorf("test %d expected log %q, got %q", i, test.Expect, buf.String())
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Level      Level
+		ShouldFail bool
+	}{
+		{Name: "debug", Level: LevelDebug},
+		{Name: "INFO", Level: LevelInfo},
+		{Name: "warn", Level: LevelWarn},
+		{Name: "error", Level: LevelError},
+		{Name: "verbose", ShouldFail: true},
+	}
+
+	for i, test := range tests {
+		level, err := ParseLevel(test.Name)
+		if err == nil && test.ShouldFail {
+			t.Fatalf("expected test %d to fail with an error", i)
+		} else if err != nil && !test.ShouldFail {
+			t.Fatalf("unexpected error for test %d: %v", i, err)
+		}
+		if !test.ShouldFail && level != test.Level {
+			t.Errorf("test %d expected level %s, got %s", i, test.Level, level)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if l := FromContext(context.Background()); l != Default() {
+		t.Errorf("expected default logger without one on the context")
+	}
+	l := New(&bytes.Buffer{}, LevelInfo, FormatText)
+	if FromContext(NewContext(context.Background(), l)) != l {
+		t.Errorf("expected logger set on the context")
+	}
+}
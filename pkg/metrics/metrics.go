@@ -0,0 +1,311 @@
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+const (
+	Namespace = "gitlab_janitor"
+
+	// LabelJob is used instead of job so it does not clash with the job
+	// label Prometheus adds when scraping.
+	LabelJob = "janitor_job"
+)
+
+// Metrics records janitor runs for Prometheus. All methods are safe to call
+// on a nil *Metrics so callers without metrics configured need no checks.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	backups         *prometheus.GaugeVec
+	backupBytes     *prometheus.GaugeVec
+	kept            prometheus.Gauge
+	pruned          prometheus.Gauge
+	unparsable      prometheus.Gauge
+	nonBackup       prometheus.Gauge
+	reclaimedBytes  prometheus.Counter
+	deleted         prometheus.Counter
+	moved           prometheus.Counter
+	deleteErrors    prometheus.Counter
+	runs            *prometheus.CounterVec
+	runDuration     prometheus.Gauge
+	lastSuccess     prometheus.Gauge
+	newestTimestamp prometheus.Gauge
+	newestSize      prometheus.Gauge
+
+	lock   sync.Mutex
+	newest time.Time
+	sizes  map[string]int64
+}
+
+func New() *Metrics {
+	return NewWithRegistry(prometheus.NewRegistry(), "")
+}
+
+// NewJobs creates metrics for each named job sharing a single registry, so
+// any of them serve or write the metrics for all.
+func NewJobs(jobs []string) map[string]*Metrics {
+	registry := prometheus.NewRegistry()
+	ms := make(map[string]*Metrics, len(jobs))
+	for _, job := range jobs {
+		ms[job] = NewWithRegistry(registry, job)
+	}
+	return ms
+}
+
+// NewWithRegistry creates metrics registered with registry. When job is set
+// every metric carries a janitor_job label so several jobs can share a
+// registry.
+func NewWithRegistry(registry *prometheus.Registry, job string) *Metrics {
+	labels := prometheus.Labels{}
+	if job != "" {
+		labels[LabelJob] = job
+	}
+	m := &Metrics{
+		registry: registry,
+		backups: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "backups",
+			Help:        "Number of parsed backups in the bucket by version and edition.",
+		}, []string{"version", "edition"}),
+		backupBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "backup_bytes",
+			Help:        "Total size of parsed backups in the bucket by version and edition.",
+		}, []string{"version", "edition"}),
+		kept: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "backups_kept",
+			Help:        "Number of backups kept by the last run.",
+		}),
+		pruned: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "backups_pruned",
+			Help:        "Number of objects selected for pruning by the last run.",
+		}),
+		unparsable: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "unparsable_objects",
+			Help:        "Number of objects that looked like backups but could not be parsed in the last run.",
+		}),
+		nonBackup: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "non_backup_objects",
+			Help:        "Number of objects that were not backups in the last run.",
+		}),
+		reclaimedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "reclaimed_bytes_total",
+			Help:        "Total bytes of storage reclaimed by deleting objects.",
+		}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "deleted_objects_total",
+			Help:        "Total number of objects deleted.",
+		}),
+		moved: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "moved_objects_total",
+			Help:        "Total number of objects moved to the quarantine.",
+		}),
+		deleteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "delete_errors_total",
+			Help:        "Total number of failed object deletions and moves.",
+		}),
+		runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "runs_total",
+			Help:        "Total number of janitor runs by result.",
+		}, []string{"result"}),
+		runDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "last_run_duration_seconds",
+			Help:        "Duration of the last janitor run.",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "last_success_timestamp_seconds",
+			Help:        "Unix time of the last successful janitor run.",
+		}),
+		newestTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "newest_backup_timestamp_seconds",
+			Help:        "Unix time of the newest parsed backup.",
+		}),
+		newestSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   Namespace,
+			ConstLabels: labels,
+			Name:        "newest_backup_size_bytes",
+			Help:        "Size of the newest parsed backup.",
+		}),
+		sizes: map[string]int64{},
+	}
+
+	newestAge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   Namespace,
+		ConstLabels: labels,
+		Name:        "newest_backup_age_seconds",
+		Help:        "Age of the newest parsed backup.",
+	}, m.newestAge)
+
+	m.registry.MustRegister(
+		m.backups,
+		m.backupBytes,
+		m.kept,
+		m.pruned,
+		m.unparsable,
+		m.nonBackup,
+		m.reclaimedBytes,
+		m.deleted,
+		m.moved,
+		m.deleteErrors,
+		m.runs,
+		m.runDuration,
+		m.lastSuccess,
+		m.newestTimestamp,
+		m.newestSize,
+		newestAge,
+	)
+	return m
+}
+
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// WriteTextfile writes the current metrics for the node exporter textfile
+// collector.
+func (m *Metrics) WriteTextfile(path string) error {
+	return prometheus.WriteToTextfile(path, m.registry)
+}
+
+func (m *Metrics) newestAge() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.newest.IsZero() {
+		return 0
+	}
+	return time.Since(m.newest).Seconds()
+}
+
+func (m *Metrics) ObservePruneResult(result *backup.PruneResult) {
+	if m == nil {
+		return
+	}
+
+	m.backups.Reset()
+	m.backupBytes.Reset()
+	kept := 0
+	sizes := map[string]int64{}
+	for _, b := range result.Backups {
+		ver, edition := "", b.Edition
+		if b.Version != nil {
+			ver = b.Version.Original()
+		}
+		m.backups.WithLabelValues(ver, edition).Inc()
+		m.backupBytes.WithLabelValues(ver, edition).Add(float64(b.Size))
+		if result.Verdicts[b].Keep {
+			kept++
+		}
+		sizes[b.Key] = b.Size
+	}
+	for _, obj := range result.Unparsable {
+		sizes[obj.Key] = obj.Size
+	}
+	for _, obj := range result.NonBackup {
+		sizes[obj.Key] = obj.Size
+	}
+
+	m.kept.Set(float64(kept))
+	m.pruned.Set(float64(len(result.Prune)))
+	m.unparsable.Set(float64(len(result.Unparsable)))
+	m.nonBackup.Set(float64(len(result.NonBackup)))
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.sizes = sizes
+	if len(result.Backups) > 0 {
+		m.newest = result.Backups[0].Time
+		m.newestTimestamp.Set(float64(m.newest.Unix()))
+		m.newestSize.Set(float64(result.Backups[0].Size))
+	}
+}
+
+// ObserveQuarantine records the size of each quarantined object so purging
+// it reclaims its bytes. Keys are the original keys of objects under prefix.
+func (m *Metrics) ObserveQuarantine(prefix string, objects []*backup.Object) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, obj := range objects {
+		m.sizes[prefix+obj.Key] = obj.Size
+	}
+}
+
+// ObserveDelete records a removal attempt with one of the audit actions for
+// a key from the last observed prune result or quarantine. Moving an object
+// to the quarantine reclaims nothing until it is purged.
+func (m *Metrics) ObserveDelete(action, key string, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.deleteErrors.Inc()
+		return
+	}
+	if action == audit.ActionMove {
+		m.moved.Inc()
+		return
+	}
+
+	m.lock.Lock()
+	size := m.sizes[key]
+	m.lock.Unlock()
+	m.deleted.Inc()
+	m.reclaimedBytes.Add(float64(size))
+}
+
+func (m *Metrics) ObserveRun(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	end := time.Now()
+	m.runDuration.Set(end.Sub(start).Seconds())
+	if err != nil {
+		m.runs.WithLabelValues("failure").Inc()
+		return
+	}
+	m.runs.WithLabelValues("success").Inc()
+	m.lastSuccess.Set(float64(end.Unix()))
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/tlmiller/gitlab-janitor/pkg/audit"
+	"github.com/tlmiller/gitlab-janitor/pkg/gitlab/backup"
+)
+
+func TestObservePruneResult(t *testing.T) {
+	parser := backup.WithGitLabKeyParser()
+	backups := backup.BackupList{}
+	for _, key := range []string{
+		"1565056820_2019_08_06_12.0.3-ee_gitlab_backup.tar",
+		"1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar",
+		"1561863334_2019_06_30_11.7.0-ce_gitlab_backup.tar",
+	} {
+		b, err := parser.Parse(key)
+		if err != nil {
+			t.Fatalf("unexpected error parsing key %s: %v", key, err)
+		}
+		b.Size = 100
+		backups = append(backups, b)
+	}
+	result := &backup.PruneResult{
+		Listing: backup.Listing{
+			Backups: backups,
+			Unparsable: []*backup.UnparsableObject{
+				{Object: backup.Object{Key: "1565056820_2019_08_06_bad_gitlab_backup.tar", Size: 10}},
+			},
+		},
+		Verdicts: map[*backup.Backup]backup.Verdict{
+			backups[0]: {Keep: true},
+			backups[1]: {Keep: false},
+			backups[2]: {Keep: true},
+		},
+		Prune: []string{backups[1].Key},
+	}
+
+	m := New()
+	m.ObservePruneResult(result)
+	m.ObserveDelete(audit.ActionDelete, backups[1].Key, nil)
+	m.ObserveDelete(audit.ActionDelete, "1565056820_2019_08_06_bad_gitlab_backup.tar", errors.New("failed"))
+	m.ObserveRun(time.Now(), nil)
+
+	tests := []struct {
+		Name     string
+		Value    float64
+		Expected float64
+	}{
+		{"ee backups", testutil.ToFloat64(m.backups.WithLabelValues("12.0.3", "ee")), 2},
+		{"ce backups", testutil.ToFloat64(m.backups.WithLabelValues("11.7.0", "ce")), 1},
+		{"ee backup bytes", testutil.ToFloat64(m.backupBytes.WithLabelValues("12.0.3", "ee")), 200},
+		{"newest backup size", testutil.ToFloat64(m.newestSize), 100},
+		{"kept", testutil.ToFloat64(m.kept), 2},
+		{"pruned", testutil.ToFloat64(m.pruned), 1},
+		{"unparsable", testutil.ToFloat64(m.unparsable), 1},
+		{"reclaimed bytes", testutil.ToFloat64(m.reclaimedBytes), 100},
+		{"delete errors", testutil.ToFloat64(m.deleteErrors), 1},
+		{"successful runs", testutil.ToFloat64(m.runs.WithLabelValues("success")), 1},
+		{"newest backup", testutil.ToFloat64(m.newestTimestamp), 1565056820},
+	}
+	for _, test := range tests {
+		if test.Value != test.Expected {
+			t.Errorf("metric %s value %v does not match expected %v", test.Name, test.Value, test.Expected)
+		}
+	}
+	if testutil.ToFloat64(m.lastSuccess) == 0 {
+		t.Error("expected last success timestamp to be set")
+	}
+}
+
+func TestObserveQuarantine(t *testing.T) {
+	key := "1564970415_2019_08_05_12.0.3-ee_gitlab_backup.tar"
+	b, err := backup.WithGitLabKeyParser().Parse(key)
+	if err != nil {
+		t.Fatalf("unexpected error parsing key %s: %v", key, err)
+	}
+	b.Size = 100
+
+	m := New()
+	m.ObservePruneResult(&backup.PruneResult{
+		Listing: backup.Listing{Backups: backup.BackupList{b}},
+		Prune:   []string{key},
+	})
+	m.ObserveDelete(audit.ActionMove, key, nil)
+
+	tests := []struct {
+		Name     string
+		Value    float64
+		Expected float64
+	}{
+		{"moved", testutil.ToFloat64(m.moved), 1},
+		{"deleted after move", testutil.ToFloat64(m.deleted), 0},
+		{"reclaimed bytes after move", testutil.ToFloat64(m.reclaimedBytes), 0},
+	}
+
+	m.ObserveQuarantine("quarantine/", []*backup.Object{{Key: key, Size: 100}})
+	m.ObserveDelete(audit.ActionPurge, "quarantine/"+key, nil)
+	tests = append(tests, []struct {
+		Name     string
+		Value    float64
+		Expected float64
+	}{
+		{"deleted after purge", testutil.ToFloat64(m.deleted), 1},
+		{"reclaimed bytes after purge", testutil.ToFloat64(m.reclaimedBytes), 100},
+	}...)
+
+	for _, test := range tests {
+		if test.Value != test.Expected {
+			t.Errorf("metric %s value %v does not match expected %v", test.Name, test.Value, test.Expected)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObservePruneResult(&backup.PruneResult{})
+	m.ObserveQuarantine("quarantine/", []*backup.Object{{Key: "key"}})
+	m.ObserveDelete(audit.ActionDelete, "key", nil)
+	m.ObserveRun(time.Now(), errors.New("failed"))
+}
+
+func TestNewJobsShareRegistry(t *testing.T) {
+	ms := NewJobs([]string{"prod", "staging"})
+	ms["prod"].ObserveRun(time.Now(), nil)
+	ms["staging"].ObserveRun(time.Now(), errors.New("failed"))
+
+	families, err := ms["prod"].Registry().Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+	jobs := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != Namespace+"_runs_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == LabelJob {
+					jobs[label.GetValue()] = true
+				}
+			}
+		}
+	}
+	if !jobs["prod"] || !jobs["staging"] {
+		t.Errorf("expected runs for both jobs in the shared registry, got %v", jobs)
+	}
+}